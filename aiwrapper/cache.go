@@ -0,0 +1,143 @@
+package aiwrapper
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultCacheEntries es la cantidad de respuestas guardadas en memoria
+const defaultCacheEntries = 100
+
+// Entrada de la caché: clave y respuesta cruda de la IA
+type cacheEntry struct {
+	Key string `json:"key"`
+	Raw string `json:"raw"`
+}
+
+// ResponseCache es una caché LRU de respuestas, opcionalmente persistida en disco
+type ResponseCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List // del más reciente al menos reciente
+	entries    map[string]*list.Element
+	dir        string // vacío si la caché en disco está desactivada
+}
+
+// Cache es la caché compartida por todas las traducciones del proceso
+var Cache = NewResponseCache()
+
+// NewResponseCache crea la caché con AI_CACHE_MAX_ENTRIES entradas; con
+// AI_CACHE=true también guarda las respuestas en ~/.cache/neri
+func NewResponseCache() *ResponseCache {
+	cache := &ResponseCache{
+		maxEntries: defaultCacheEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+	if value, err := strconv.Atoi(os.Getenv("AI_CACHE_MAX_ENTRIES")); err == nil && value > 0 {
+		cache.maxEntries = value
+	}
+	if GetEnvBool("AI_CACHE", false) {
+		if base, err := os.UserCacheDir(); err == nil {
+			cache.dir = filepath.Join(base, "neri")
+		}
+	}
+	return cache
+}
+
+// cacheKey normaliza el prompt y lo combina con proveedor, modelo e instrucción de sistema
+func cacheKey(config AIConfig, system, prompt string) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(prompt)), " ")
+	return config.Provider + "\x00" + config.Model + "\x00" + system + "\x00" + normalized
+}
+
+// Get busca la respuesta en memoria y, si no está, en disco
+func (c *ResponseCache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.order.MoveToFront(element)
+		return element.Value.(cacheEntry).Raw, true
+	}
+	if c.dir == "" {
+		return "", false
+	}
+
+	data, err := os.ReadFile(c.diskPath(key))
+	if err != nil {
+		return "", false
+	}
+	var entry cacheEntry
+	// La clave completa se guarda para descartar colisiones del nombre de archivo
+	if json.Unmarshal(data, &entry) != nil || entry.Key != key {
+		return "", false
+	}
+	c.add(entry)
+	return entry.Raw, true
+}
+
+// Put guarda la respuesta en memoria y, si está activada, en disco
+func (c *ResponseCache) Put(key, raw string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := cacheEntry{Key: key, Raw: raw}
+	c.add(entry)
+	if c.dir == "" {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	// Un fallo de escritura solo hace que la próxima sesión vuelva a llamar a la API
+	if os.MkdirAll(c.dir, 0700) == nil {
+		os.WriteFile(c.diskPath(key), data, 0600)
+	}
+}
+
+// add inserta o actualiza la entrada y descarta la menos usada si se supera el límite
+func (c *ResponseCache) add(entry cacheEntry) {
+	if element, ok := c.entries[entry.Key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[entry.Key] = c.order.PushFront(entry)
+	if c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(cacheEntry).Key)
+	}
+}
+
+// ClearMemory vacía solo la caché en memoria de la sesión
+func (c *ResponseCache) ClearMemory() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+}
+
+// Clear vacía la caché en memoria y borra la de disco
+func (c *ResponseCache) Clear() error {
+	c.ClearMemory()
+	if c.dir == "" {
+		return nil
+	}
+	return os.RemoveAll(c.dir)
+}
+
+// diskPath devuelve el archivo de la entrada en disco
+func (c *ResponseCache) diskPath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
@@ -0,0 +1,157 @@
+package aiwrapper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestTranslateDoesNotCacheFallbackAnswers(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("AI_CACHE", "false")
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"clave inválida"}}`, http.StatusUnauthorized)
+	}))
+	defer primary.Close()
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"content":"ls -la"},"finish_reason":"stop"}]}`))
+	}))
+	defer fallback.Close()
+
+	t.Setenv("AI_PROVIDER", "openai")
+	t.Setenv("AI_BASE_URL", primary.URL)
+	t.Setenv("AI_API_KEY", "sk-primaria")
+	t.Setenv("AI_FALLBACK_PROVIDER", "openai")
+	t.Setenv("AI_FALLBACK_BASE_URL", fallback.URL)
+	t.Setenv("AI_FALLBACK_MODEL", "gpt-4o-mini")
+	t.Setenv("AI_FALLBACK_API_KEY", "sk-respaldo")
+
+	saved := Cache
+	Cache = NewResponseCache()
+	defer func() { Cache = saved }()
+
+	result, err := TranslateWithContext(context.Background(), "listar archivos", nil)
+	if err != nil {
+		t.Fatalf("TranslateWithContext() error = %v", err)
+	}
+	if result.Model != "gpt-4o-mini" {
+		t.Errorf("result.Model = %q, want the fallback model", result.Model)
+	}
+
+	system := translationSystemPrompt()
+	if _, ok := Cache.Get(cacheKey(GetAIConfig(), system, "listar archivos")); ok {
+		t.Error("fallback response cached under the primary provider key")
+	}
+	fallbackConfig, _ := GetFallbackConfig()
+	if _, ok := Cache.Get(cacheKey(fallbackConfig, system, "listar archivos")); ok {
+		t.Error("fallback response cached under a key that is never looked up")
+	}
+}
+
+func TestTranslateCachesRepeatedPrompt(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("AI_CACHE", "false")
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"content":"ls -la"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("AI_PROVIDER", "openai")
+	t.Setenv("AI_BASE_URL", server.URL)
+	t.Setenv("AI_API_KEY", "sk-test-0123456789abcdef")
+	t.Setenv("AI_FALLBACK_PROVIDER", "")
+
+	saved := Cache
+	Cache = NewResponseCache()
+	defer func() { Cache = saved }()
+
+	first, err := TranslateWithContext(context.Background(), "listar archivos", nil)
+	if err != nil {
+		t.Fatalf("TranslateWithContext() error = %v", err)
+	}
+	// Mayúsculas y espacios extra se normalizan a la misma clave
+	second, err := TranslateWithContext(context.Background(), "  Listar   ARCHIVOS ", nil)
+	if err != nil {
+		t.Fatalf("TranslateWithContext() repeated error = %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("API calls = %d, want 1 for a repeated prompt", calls)
+	}
+	if first.Cached || !second.Cached || second.Command != first.Command {
+		t.Errorf("results = %+v, %+v, want the second one served from the cache", first, second)
+	}
+
+	// reset vacía la caché de la sesión
+	Cache.ClearMemory()
+	if _, err := TranslateWithContext(context.Background(), "listar archivos", nil); err != nil {
+		t.Fatalf("TranslateWithContext() after ClearMemory error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("API calls after ClearMemory = %d, want 2", calls)
+	}
+}
+
+func TestResponseCacheDiskRoundTrip(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("AI_CACHE", "true")
+	t.Setenv("AI_CACHE_MAX_ENTRIES", "")
+
+	key := cacheKey(AIConfig{Provider: "openai", Model: "gpt-4o"}, "sistema", "  Listar   ARCHIVOS ")
+	if other := cacheKey(AIConfig{Provider: "openai", Model: "gpt-4o"}, "sistema", "listar archivos"); other != key {
+		t.Errorf("cacheKey does not normalize the prompt: %q != %q", key, other)
+	}
+
+	first := NewResponseCache()
+	if first.dir == "" {
+		t.Fatal("NewResponseCache() with AI_CACHE=true has no disk directory")
+	}
+	first.Put(key, "ls -la")
+
+	// Una caché nueva (otra sesión) lee la entrada desde disco
+	second := NewResponseCache()
+	if raw, ok := second.Get(key); !ok || raw != "ls -la" {
+		t.Errorf("Get() from disk = %q, %v, want \"ls -la\", true", raw, ok)
+	}
+	if _, ok := second.Get(key + "x"); ok {
+		t.Error("Get() of a missing key found an entry")
+	}
+
+	// Un archivo con otra clave completa (colisión de nombre) se descarta
+	if err := os.WriteFile(second.diskPath("otra"), []byte(`{"key":"distinta","raw":"rm -rf ~"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := second.Get("otra"); ok {
+		t.Error("Get() accepted an entry whose stored key does not match")
+	}
+
+	if err := second.Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if _, ok := NewResponseCache().Get(key); ok {
+		t.Error("Get() after Clear() still finds the disk entry")
+	}
+}
+
+func TestResponseCacheEviction(t *testing.T) {
+	t.Setenv("AI_CACHE", "false")
+	t.Setenv("AI_CACHE_MAX_ENTRIES", "2")
+	cache := NewResponseCache()
+	cache.Put("a", "1")
+	cache.Put("b", "2")
+	cache.Get("a")
+	cache.Put("c", "3")
+	if _, ok := cache.Get("b"); ok {
+		t.Error("least recently used entry was not evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("entry %q was evicted", key)
+		}
+	}
+}
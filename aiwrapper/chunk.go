@@ -0,0 +1,89 @@
+package aiwrapper
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// defaultChunkSize es el tamaño de cada fragmento en caracteres
+const defaultChunkSize = 4000
+
+// chunkSummaryMaxTokens limita el resumen de cada fragmento
+const chunkSummaryMaxTokens = 300
+
+// chunkSystemPrompt pide un resumen del fragmento conservando lo relevante
+const chunkSystemPrompt = "Resume el siguiente fragmento de texto de forma breve, conservando nombres de archivos, rutas, errores y cifras relevantes. Responde SOLO con el resumen."
+
+// GetChunkSize obtiene AI_CHUNK_SIZE o el valor por defecto
+func GetChunkSize() int {
+	if value, err := strconv.Atoi(os.Getenv("AI_CHUNK_SIZE")); err == nil && value > 0 {
+		return value
+	}
+	return defaultChunkSize
+}
+
+// ShouldChunkPrompt verifica si el prompt debe dividirse antes de enviarlo
+func ShouldChunkPrompt(text string) bool {
+	return GetEnvBool("AI_CHUNK_PROMPT", false) && len([]rune(text)) > GetChunkSize()
+}
+
+// SplitIntoChunks divide el texto en fragmentos de hasta size caracteres, cortando en saltos de línea
+func SplitIntoChunks(text string, size int) []string {
+	var chunks []string
+	var current strings.Builder
+	currentLen := 0
+
+	flush := func() {
+		if currentLen > 0 {
+			chunks = append(chunks, current.String())
+			current.Reset()
+			currentLen = 0
+		}
+	}
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		runes := []rune(line)
+		// Líneas más largas que un fragmento se cortan directamente
+		for len(runes) > size {
+			flush()
+			chunks = append(chunks, string(runes[:size]))
+			runes = runes[size:]
+		}
+		if currentLen+len(runes) > size {
+			flush()
+		}
+		current.WriteString(string(runes))
+		currentLen += len(runes)
+	}
+	flush()
+	return chunks
+}
+
+// ChunkAndCombine resume cada fragmento del texto y combina los resultados
+func ChunkAndCombine(ctx context.Context, text string) (string, error) {
+	chunks := SplitIntoChunks(text, GetChunkSize())
+
+	summaries := make([]string, 0, len(chunks))
+	for i, chunk := range chunks {
+		summary, err := CallAIAPI(ctx, AIRequest{System: chunkSystemPrompt, Prompt: chunk, MaxTokens: chunkSummaryMaxTokens})
+		if err != nil {
+			return "", fmt.Errorf("error procesando fragmento %d/%d: %v", i+1, len(chunks), err)
+		}
+		summaries = append(summaries, strings.TrimSpace(cleanResponse(summary)))
+	}
+	return combineSummaries(summaries), nil
+}
+
+// combineSummaries une los resúmenes de los fragmentos en un solo texto
+func combineSummaries(summaries []string) string {
+	var nonEmpty []string
+	for _, summary := range summaries {
+		if summary != "" {
+			nonEmpty = append(nonEmpty, summary)
+		}
+	}
+	return strings.Join(nonEmpty, "\n")
+}
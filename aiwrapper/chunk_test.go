@@ -0,0 +1,80 @@
+package aiwrapper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitIntoChunks(t *testing.T) {
+	tests := []struct {
+		text string
+		size int
+		want []string
+	}{
+		{"", 10, nil},
+		{"abc", 3, []string{"abc"}},
+		{"abcd", 3, []string{"abc", "d"}},
+		{"ab\ncd\nef", 6, []string{"ab\ncd\n", "ef"}},
+		{"ab\ncd\nef", 5, []string{"ab\n", "cd\nef"}},
+		{"ab\ncd\nef", 4, []string{"ab\n", "cd\n", "ef"}},
+		{"corto\nlínea-muy-larga\n", 6, []string{"corto\n", "línea-", "muy-la", "rga\n"}},
+		{"ñandú€😀", 2, []string{"ña", "nd", "ú€", "😀"}},
+	}
+	for _, tt := range tests {
+		got := SplitIntoChunks(tt.text, tt.size)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitIntoChunks(%q, %d) = %q, want %q", tt.text, tt.size, got, tt.want)
+		}
+		if strings.Join(got, "") != tt.text {
+			t.Errorf("SplitIntoChunks(%q, %d) loses text: %q", tt.text, tt.size, got)
+		}
+		for _, chunk := range got {
+			if !utf8.ValidString(chunk) || utf8.RuneCountInString(chunk) > tt.size {
+				t.Errorf("SplitIntoChunks(%q, %d) chunk %q is invalid UTF-8 or too long", tt.text, tt.size, chunk)
+			}
+		}
+	}
+}
+
+func TestShouldChunkPrompt(t *testing.T) {
+	tests := []struct {
+		enabled, size, text string
+		want                bool
+	}{
+		{"", "4", "texto largo", false},
+		{"true", "", strings.Repeat("a", defaultChunkSize), false},
+		{"true", "", strings.Repeat("a", defaultChunkSize+1), true},
+		{"true", "5", "abcde", false},
+		{"true", "5", "abcdef", true},
+		// Se cuentan runas, no bytes
+		{"true", "5", "ñañañ", false},
+		{"true", "abc", strings.Repeat("a", defaultChunkSize+1), true},
+	}
+	for _, tt := range tests {
+		t.Setenv("AI_CHUNK_PROMPT", tt.enabled)
+		t.Setenv("AI_CHUNK_SIZE", tt.size)
+		if got := ShouldChunkPrompt(tt.text); got != tt.want {
+			t.Errorf("ShouldChunkPrompt(%d runes) with AI_CHUNK_PROMPT=%q, AI_CHUNK_SIZE=%q = %v, want %v",
+				utf8.RuneCountInString(tt.text), tt.enabled, tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestCombineSummaries(t *testing.T) {
+	tests := []struct {
+		summaries []string
+		want      string
+	}{
+		{nil, ""},
+		{[]string{"uno"}, "uno"},
+		{[]string{"uno", "", "dos"}, "uno\ndos"},
+		{[]string{"", ""}, ""},
+	}
+	for _, tt := range tests {
+		if got := combineSummaries(tt.summaries); got != tt.want {
+			t.Errorf("combineSummaries(%q) = %q, want %q", tt.summaries, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package aiwrapper
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// configFileName es el archivo de configuración global dentro de ConfigDir
+const configFileName = "config.json"
+
+// Configuración global leída de ~/.config/neri/config.json
+type ConfigFile struct {
+	Provider string `json:"provider"`
+	BaseURL  string `json:"base_url"`
+	APIKey   string `json:"api_key"`
+	Model    string `json:"model"`
+	Timeout  *int   `json:"timeout"` // segundos; 0 desactiva el timeout
+}
+
+// ConfigDir obtiene el directorio de configuración del shell
+func ConfigDir() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "neri"), nil
+}
+
+// ConfigFilePath devuelve AI_CONFIG_FILE o ~/.config/neri/config.json
+func ConfigFilePath() string {
+	if path := os.Getenv("AI_CONFIG_FILE"); path != "" {
+		return path
+	}
+	dir, err := ConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, configFileName)
+}
+
+// loadConfigFile lee el archivo de configuración global; si no existe devuelve una configuración vacía
+func loadConfigFile() ConfigFile {
+	var config ConfigFile
+	path := ConfigFilePath()
+	if path == "" {
+		return config
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			warnOnce(path, "⚠️  No se pudo leer %s: %v\n", path, err)
+		}
+		return config
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		warnOnce(path, "⚠️  %s inválido, se ignora: %v\n", path, err)
+		return ConfigFile{}
+	}
+	return config
+}
+
+// settings convierte el archivo en opciones AI_* con la forma de las del .neri
+func (c ConfigFile) settings() map[string]string {
+	settings := make(map[string]string)
+	for key, value := range map[string]string{
+		"AI_PROVIDER": c.Provider,
+		"AI_BASE_URL": c.BaseURL,
+		"AI_MODEL":    c.Model,
+	} {
+		if value != "" {
+			settings[key] = value
+		}
+	}
+	return settings
+}
+
+// timeout devuelve el timeout del archivo, o defaultValue si no lo define
+func (c ConfigFile) timeout(defaultValue time.Duration) time.Duration {
+	if c.Timeout == nil || *c.Timeout < 0 {
+		return defaultValue
+	}
+	return time.Duration(*c.Timeout) * time.Second
+}
+
+// mergeSettings combina capas de opciones; las primeras tienen prioridad
+func mergeSettings(layers ...map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for i := len(layers) - 1; i >= 0; i-- {
+		for key, value := range layers[i] {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+// warnings evita repetir una advertencia de configuración en cada llamada
+var warnings struct {
+	sync.Mutex
+	seen map[string]bool
+}
+
+// warnOnce escribe la advertencia en stderr solo la primera vez para key
+func warnOnce(key, format string, args ...interface{}) {
+	warnings.Lock()
+	defer warnings.Unlock()
+	if warnings.seen[key] {
+		return
+	}
+	if warnings.seen == nil {
+		warnings.seen = make(map[string]bool)
+	}
+	warnings.seen[key] = true
+	fmt.Fprintf(os.Stderr, format, args...)
+}
@@ -0,0 +1,81 @@
+package aiwrapper
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// defaultContextTurns es la cantidad de turnos previos enviados como contexto
+const defaultContextTurns = 5
+
+// Turno previo de la conversación: petición del usuario y comando respondido
+type ChatTurn struct {
+	User      string
+	Assistant string
+}
+
+// Conversation guarda los últimos turnos para que las peticiones de seguimiento
+// ("ahora lo mismo pero recursivo") tengan contexto
+type Conversation struct {
+	turns    []ChatTurn
+	maxTurns int
+}
+
+// NewConversation crea una conversación limitada a AI_CONTEXT_TURNS turnos (0 la desactiva)
+func NewConversation() *Conversation {
+	return &Conversation{maxTurns: getContextTurns()}
+}
+
+// getContextTurns obtiene el límite de turnos de AI_CONTEXT_TURNS
+func getContextTurns() int {
+	if value, err := strconv.Atoi(os.Getenv("AI_CONTEXT_TURNS")); err == nil && value >= 0 {
+		return value
+	}
+	return defaultContextTurns
+}
+
+// Add agrega un turno, descartando los más antiguos si se supera el límite
+func (c *Conversation) Add(user, assistant string) {
+	if c.maxTurns <= 0 {
+		return
+	}
+	c.turns = append(c.turns, ChatTurn{User: user, Assistant: assistant})
+	if len(c.turns) > c.maxTurns {
+		c.turns = c.turns[len(c.turns)-c.maxTurns:]
+	}
+}
+
+// Clear olvida todos los turnos previos
+func (c *Conversation) Clear() {
+	c.turns = nil
+}
+
+// Turns devuelve los turnos guardados, del más antiguo al más reciente
+func (c *Conversation) Turns() []ChatTurn {
+	return c.turns
+}
+
+// chatMessages arma los mensajes user/assistant previos seguidos del prompt actual
+func chatMessages(history []ChatTurn, prompt string) []map[string]string {
+	messages := make([]map[string]string, 0, 2*len(history)+1)
+	for _, turn := range history {
+		messages = append(messages,
+			map[string]string{"role": "user", "content": turn.User},
+			map[string]string{"role": "assistant", "content": turn.Assistant},
+		)
+	}
+	return append(messages, map[string]string{"role": "user", "content": prompt})
+}
+
+// concatPrompt arma un único prompt de texto para proveedores sin lista de mensajes
+func concatPrompt(system string, history []ChatTurn, prompt string) string {
+	var text strings.Builder
+	text.WriteString(system)
+	for _, turn := range history {
+		fmt.Fprintf(&text, " Usuario: %s Asistente: %s", turn.User, turn.Assistant)
+	}
+	fmt.Fprintf(&text, " Usuario: %s", prompt)
+	return text.String()
+}
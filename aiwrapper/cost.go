@@ -0,0 +1,38 @@
+package aiwrapper
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// priceKey es la variable con el precio por 1K tokens del proveedor, p. ej. AI_OPENAI_PRICE_PER_1K
+func priceKey(provider string) string {
+	return "AI_" + strings.ToUpper(strings.ReplaceAll(provider, "-", "_")) + "_PRICE_PER_1K"
+}
+
+// getPricePer1K lee el precio en dólares por cada 1000 tokens del proveedor;
+// false si no está configurado o es inválido
+func getPricePer1K(provider string) (float64, bool) {
+	key := priceKey(provider)
+	value := os.Getenv(key)
+	if value == "" {
+		return 0, false
+	}
+	price, err := strconv.ParseFloat(value, 64)
+	if err != nil || price < 0 {
+		warnOnce(key, "⚠️  %s inválido (%q), debe ser un número no negativo\n", key, value)
+		return 0, false
+	}
+	return price, true
+}
+
+// EstimateCost calcula el costo estimado de tokens con el precio configurado del
+// proveedor; false si el proveedor no tiene precio
+func EstimateCost(provider string, tokens int) (float64, bool) {
+	price, ok := getPricePer1K(provider)
+	if !ok {
+		return 0, false
+	}
+	return float64(tokens) / 1000 * price, true
+}
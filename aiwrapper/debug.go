@@ -0,0 +1,15 @@
+package aiwrapper
+
+import (
+	"fmt"
+	"os"
+)
+
+// debugf escribe un mensaje de diagnóstico en stderr, con las API keys ocultas,
+// solo si AI_DEBUG está activado
+func debugf(format string, args ...interface{}) {
+	if !GetEnvBool("AI_DEBUG", false) {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "[debug] "+Redact(fmt.Sprintf(format, args...)))
+}
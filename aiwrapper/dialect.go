@@ -0,0 +1,47 @@
+package aiwrapper
+
+import (
+	"regexp"
+	"strings"
+)
+
+// bashisms relaciona cada construcción no POSIX con su patrón
+var bashisms = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"[[ ]]", regexp.MustCompile(`\[\[`)},
+	{"sustitución de procesos <( )", regexp.MustCompile(`[<>]\(`)},
+	{"palabra clave function", regexp.MustCompile(`(^|[;&|{]\s*)function\s+\w+`)},
+	{"arrays", regexp.MustCompile(`\w+=\(|\$\{\w+\[`)},
+	{"redirección &>", regexp.MustCompile(`&>`)},
+	{"expansión {a..b}", regexp.MustCompile(`\{\w+\.\.\w+\}`)},
+	{"source", regexp.MustCompile(`(^|[;&|]\s*)source\s`)},
+}
+
+// quotedRegex reconoce cadenas entre comillas simples o dobles
+var quotedRegex = regexp.MustCompile(`'[^']*'|"(?:[^"\\]|\\.)*"`)
+
+// GetShellDialect obtiene el dialecto objetivo desde AI_SHELL_DIALECT (por defecto: bash)
+func GetShellDialect() string {
+	return strings.ToLower(getEnvOrDefault("AI_SHELL_DIALECT", "bash"))
+}
+
+// IsPOSIXDialect verifica si el dialecto objetivo es POSIX sh
+func IsPOSIXDialect(dialect string) bool {
+	return dialect == "posix" || dialect == "sh"
+}
+
+// DetectBashisms devuelve las construcciones específicas de bash presentes en el comando
+func DetectBashisms(cmd string) []string {
+	// Ignorar el contenido entre comillas
+	cmd = quotedRegex.ReplaceAllString(cmd, `""`)
+
+	var found []string
+	for _, bashism := range bashisms {
+		if bashism.pattern.MatchString(cmd) {
+			found = append(found, bashism.name)
+		}
+	}
+	return found
+}
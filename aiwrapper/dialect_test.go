@@ -0,0 +1,30 @@
+package aiwrapper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectBashisms(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want []string
+	}{
+		{"[ -f archivo ] && echo existe", nil},
+		{"find . -name '*.txt' | xargs wc -l", nil},
+		{"[[ -f archivo ]] && echo existe", []string{"[[ ]]"}},
+		{"diff <(ls a) <(ls b)", []string{"sustitución de procesos <( )"}},
+		{"make &> build.log", []string{"redirección &>"}},
+		{"for i in {1..5}; do echo $i; done", []string{"expansión {a..b}"}},
+		{"source ~/.bashrc", []string{"source"}},
+		{"function saludar { echo hola; }", []string{"palabra clave function"}},
+		{"arr=(a b c)", []string{"arrays"}},
+		{"echo '[[ no cuenta ]]'", nil},
+		{`echo "diff <(ls)"`, nil},
+	}
+	for _, tt := range tests {
+		if got := DetectBashisms(tt.cmd); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("DetectBashisms(%q) = %q, want %q", tt.cmd, got, tt.want)
+		}
+	}
+}
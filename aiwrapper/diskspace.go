@@ -0,0 +1,47 @@
+package aiwrapper
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// writeCommandRegex reconoce comandos que suelen escribir archivos grandes
+var writeCommandRegex = regexp.MustCompile(`(^|[|;&]\s*)(sudo\s+)?(dd|cp|mv|rsync|tar|zip|gzip|fallocate|truncate|wget|curl)\b|>`)
+
+// mayWriteLargeFiles verifica si el comando podría escribir archivos grandes
+func mayWriteLargeFiles(cmd string) bool {
+	return writeCommandRegex.MatchString(cmd)
+}
+
+// getMinFreeMB obtiene el umbral de AI_MIN_FREE_MB; 0 desactiva la verificación
+func getMinFreeMB() uint64 {
+	value, err := strconv.ParseUint(os.Getenv("AI_MIN_FREE_MB"), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// isLowDiskSpace verifica si el espacio libre está por debajo del umbral
+func isLowDiskSpace(freeMB, minFreeMB uint64) bool {
+	return minFreeMB > 0 && freeMB < minFreeMB
+}
+
+// CheckDiskSpace devuelve una advertencia si el comando escribe y el disco está casi lleno
+func CheckDiskSpace(cmd, dir string) string {
+	minFreeMB := getMinFreeMB()
+	if minFreeMB == 0 || !mayWriteLargeFiles(cmd) {
+		return ""
+	}
+
+	freeMB, err := freeSpaceMB(dir)
+	if err != nil {
+		return ""
+	}
+	if isLowDiskSpace(freeMB, minFreeMB) {
+		return fmt.Sprintf("⚠️  Poco espacio libre en %s: %d MB (mínimo %d MB)", dir, freeMB, minFreeMB)
+	}
+	return ""
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package aiwrapper
+
+import "errors"
+
+// freeSpaceMB no está soportado fuera de Unix
+func freeSpaceMB(dir string) (uint64, error) {
+	return 0, errors.New("verificación de espacio en disco no soportada")
+}
@@ -0,0 +1,88 @@
+package aiwrapper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMayWriteLargeFiles(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want bool
+	}{
+		{"ls -la", false},
+		{"cat notas.txt", false},
+		{"grep -r tar .", false},
+		{"dd if=/dev/zero of=archivo bs=1M count=100", true},
+		{"sudo cp -r /datos /respaldo", true},
+		{"tar czf respaldo.tgz ~/fotos", true},
+		{"wget https://example.com/iso", true},
+		{"ls | gzip -c", true},
+		{"make; rsync -a a/ b/", true},
+		{"echo hola > salida.txt", true},
+		{"copy archivo", false},
+	}
+	for _, tt := range tests {
+		if got := mayWriteLargeFiles(tt.cmd); got != tt.want {
+			t.Errorf("mayWriteLargeFiles(%q) = %v, want %v", tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestGetMinFreeMB(t *testing.T) {
+	tests := []struct {
+		value string
+		want  uint64
+	}{
+		{"", 0},
+		{"500", 500},
+		{"0", 0},
+		{"-10", 0},
+		{"mucho", 0},
+	}
+	for _, tt := range tests {
+		t.Setenv("AI_MIN_FREE_MB", tt.value)
+		if got := getMinFreeMB(); got != tt.want {
+			t.Errorf("getMinFreeMB() with AI_MIN_FREE_MB=%q = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestIsLowDiskSpace(t *testing.T) {
+	tests := []struct {
+		free, min uint64
+		want      bool
+	}{
+		{100, 0, false},
+		{100, 500, true},
+		{500, 500, false},
+		{1000, 500, false},
+	}
+	for _, tt := range tests {
+		if got := isLowDiskSpace(tt.free, tt.min); got != tt.want {
+			t.Errorf("isLowDiskSpace(%d, %d) = %v, want %v", tt.free, tt.min, got, tt.want)
+		}
+	}
+}
+
+func TestCheckDiskSpace(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("AI_MIN_FREE_MB", "")
+	if got := CheckDiskSpace("cp a b", dir); got != "" {
+		t.Errorf("CheckDiskSpace() without AI_MIN_FREE_MB = %q, want empty", got)
+	}
+
+	// Ningún disco tiene tanto espacio libre
+	t.Setenv("AI_MIN_FREE_MB", "18446744073709551615")
+	if got := CheckDiskSpace("ls -la", dir); got != "" {
+		t.Errorf("CheckDiskSpace(ls) = %q, want empty for a read-only command", got)
+	}
+	if got := CheckDiskSpace("cp a b", dir); !strings.Contains(got, "Poco espacio libre en "+dir) {
+		t.Errorf("CheckDiskSpace(cp) = %q, want a low space warning", got)
+	}
+
+	t.Setenv("AI_MIN_FREE_MB", "1")
+	if got := CheckDiskSpace("cp a b", dir); got != "" {
+		t.Errorf("CheckDiskSpace(cp) with a 1 MB threshold = %q, want empty", got)
+	}
+}
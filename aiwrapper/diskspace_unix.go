@@ -0,0 +1,14 @@
+//go:build unix
+
+package aiwrapper
+
+import "syscall"
+
+// freeSpaceMB obtiene el espacio disponible en MB para el directorio dado
+func freeSpaceMB(dir string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize) / (1024 * 1024), nil
+}
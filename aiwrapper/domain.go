@@ -0,0 +1,77 @@
+package aiwrapper
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Dominio de herramientas: binarios esperados e instrucción para el modelo
+type domainSpec struct {
+	binaries    []string
+	instruction string
+}
+
+// domainSpecs son los dominios conocidos de AI_DOMAIN
+var domainSpecs = map[string]domainSpec{
+	"aws": {
+		binaries:    []string{"aws"},
+		instruction: "Usa exclusivamente la CLI de AWS (aws <servicio> <operación>) con flags reales como --query, --filters y --output.",
+	},
+	"gcp": {
+		binaries:    []string{"gcloud", "gsutil", "bq"},
+		instruction: "Usa exclusivamente las CLIs de Google Cloud (gcloud, gsutil, bq) con flags reales como --format y --filter.",
+	},
+	"kubectl": {
+		binaries:    []string{"kubectl"},
+		instruction: "Usa exclusivamente kubectl, con -n para el namespace y -o para el formato de salida cuando corresponda.",
+	},
+	"docker": {
+		binaries:    []string{"docker", "docker-compose"},
+		instruction: "Usa exclusivamente la CLI de Docker (docker, docker compose) con flags reales.",
+	},
+}
+
+// GetDomain obtiene el dominio de AI_DOMAIN en minúsculas, o "" si no hay
+func GetDomain() string {
+	return strings.ToLower(strings.TrimSpace(os.Getenv("AI_DOMAIN")))
+}
+
+// getDomainSpec devuelve el dominio conocido o uno genérico cuyo binario es el propio nombre
+func getDomainSpec(domain string) domainSpec {
+	if spec, ok := domainSpecs[domain]; ok {
+		return spec
+	}
+	return domainSpec{
+		binaries:    []string{domain},
+		instruction: fmt.Sprintf("Usa exclusivamente comandos de %s.", domain),
+	}
+}
+
+// domainHint devuelve la instrucción del dominio para el prompt de sistema
+func domainHint(domain string) string {
+	if domain == "" {
+		return ""
+	}
+	return " " + getDomainSpec(domain).instruction
+}
+
+// DomainMismatch devuelve una advertencia si el binario del comando no pertenece
+// al dominio; sin binario reconocible no hay nada que advertir
+func DomainMismatch(cmd, domain string) string {
+	if domain == "" {
+		return ""
+	}
+	binary := commandBinary(cmd)
+	if binary == "" {
+		return ""
+	}
+	binary = filepath.Base(binary)
+	for _, expected := range getDomainSpec(domain).binaries {
+		if binary == expected {
+			return ""
+		}
+	}
+	return fmt.Sprintf("el comando usa %s en lugar de una herramienta de %s", binary, domain)
+}
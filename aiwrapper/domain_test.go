@@ -0,0 +1,58 @@
+package aiwrapper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDomainMismatch(t *testing.T) {
+	tests := []struct {
+		cmd, domain, want string
+	}{
+		{"aws s3 ls", "aws", ""},
+		{"/usr/local/bin/aws ec2 describe-instances", "aws", ""},
+		{"sudo kubectl get pods", "kubectl", ""},
+		{"gsutil ls gs://bucket", "gcp", ""},
+		{"docker-compose up -d", "docker", ""},
+		{"terraform plan", "terraform", ""},
+		{"ls -la", "", ""},
+		{"", "aws", ""},
+		{"| grep x", "aws", ""},
+		{"curl https://s3.amazonaws.com", "aws", "el comando usa curl en lugar de una herramienta de aws"},
+		{"helm install x", "kubectl", "el comando usa helm en lugar de una herramienta de kubectl"},
+	}
+	for _, tt := range tests {
+		if got := DomainMismatch(tt.cmd, tt.domain); got != tt.want {
+			t.Errorf("DomainMismatch(%q, %q) = %q, want %q", tt.cmd, tt.domain, got, tt.want)
+		}
+	}
+}
+
+func TestGetDomainSpec(t *testing.T) {
+	if spec := getDomainSpec("gcp"); len(spec.binaries) != 3 || spec.binaries[0] != "gcloud" {
+		t.Errorf("getDomainSpec(gcp).binaries = %v, want gcloud, gsutil, bq", spec.binaries)
+	}
+	spec := getDomainSpec("terraform")
+	if len(spec.binaries) != 1 || spec.binaries[0] != "terraform" || spec.instruction != "Usa exclusivamente comandos de terraform." {
+		t.Errorf("getDomainSpec(terraform) = %+v, want a generic spec for terraform", spec)
+	}
+}
+
+func TestDomainHint(t *testing.T) {
+	if got := domainHint(""); got != "" {
+		t.Errorf("domainHint(\"\") = %q, want empty", got)
+	}
+	if got := domainHint("kubectl"); !strings.HasPrefix(got, " Usa exclusivamente kubectl") {
+		t.Errorf("domainHint(kubectl) = %q, want the kubectl instruction", got)
+	}
+	if got := domainHint("terraform"); got != " Usa exclusivamente comandos de terraform." {
+		t.Errorf("domainHint(terraform) = %q", got)
+	}
+}
+
+func TestGetDomain(t *testing.T) {
+	t.Setenv("AI_DOMAIN", "  AWS ")
+	if got := GetDomain(); got != "aws" {
+		t.Errorf("GetDomain() = %q, want aws", got)
+	}
+}
@@ -0,0 +1,73 @@
+package aiwrapper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// GetFallbackConfig arma la configuración de AI_FALLBACK_PROVIDER. Las opciones
+// del principal (AI_BASE_URL, AI_MODEL, AI_API_KEY) no aplican al respaldo, que
+// usa sus propias AI_FALLBACK_BASE_URL, AI_FALLBACK_MODEL y AI_FALLBACK_API_KEY.
+func GetFallbackConfig() (AIConfig, bool) {
+	provider := os.Getenv("AI_FALLBACK_PROVIDER")
+	preset, ok := providerPresets[provider]
+	if !ok {
+		return AIConfig{}, false
+	}
+
+	config := AIConfig{
+		Provider:      provider,
+		BaseURL:       getEnvOrDefault("AI_FALLBACK_BASE_URL", preset.BaseURL),
+		Model:         getEnvOrDefault("AI_FALLBACK_MODEL", preset.DefaultModel),
+		AuthStyle:     preset.AuthStyle,
+		PayloadStyle:  preset.PayloadStyle,
+		APIVersion:    preset.DefaultVersion,
+		VersionHeader: preset.VersionHeader,
+		VersionQuery:  preset.VersionQuery,
+	}
+	if preset.PayloadStyle == payloadLocal {
+		config.Model = os.Getenv("AI_FALLBACK_MODEL_PATH")
+	}
+	if preset.DeploymentPath != "" {
+		config.BaseURL = strings.TrimRight(config.BaseURL, "/") + fmt.Sprintf(preset.DeploymentPath, config.Model)
+	}
+	if preset.AuthStyle != AuthNone {
+		config.APIKey = os.Getenv("AI_FALLBACK_API_KEY")
+	}
+	config.Timeout = getProviderTimeout(provider, defaultTimeout)
+	config.MaxTokens = getMaxTokens(config.Model)
+	config.Temperature = getTemperature()
+	return config, true
+}
+
+// callWithFallback llama al proveedor principal y, si falla (red caída, error de
+// autenticación, etc.), repite la llamada con AI_FALLBACK_PROVIDER
+func callWithFallback(ctx context.Context, request AIRequest, out io.Writer) (AIResponse, AIConfig, error) {
+	config := GetAIConfig()
+	response, err := callWithConfig(ctx, config, request, out)
+	if err == nil || ctx.Err() != nil {
+		return response, config, err
+	}
+
+	fallback, ok := GetFallbackConfig()
+	if !ok || fallback.Provider == config.Provider && fallback.Model == config.Model {
+		return response, config, err
+	}
+	fallbackResponse, fallbackErr := callWithConfig(ctx, fallback, request, out)
+	if fallbackErr != nil {
+		return fallbackResponse, fallback, fmt.Errorf("%s: %v; respaldo %s: %v", config.Provider, err, fallback.Provider, fallbackErr)
+	}
+	return fallbackResponse, fallback, nil
+}
+
+// callWithConfig llama a la IA con una configuración explícita, en streaming si out no es nil
+func callWithConfig(ctx context.Context, config AIConfig, request AIRequest, out io.Writer) (AIResponse, error) {
+	request.Config = &config
+	if out != nil {
+		return callAIAPIStream(ctx, request, out)
+	}
+	return callAI(ctx, request)
+}
@@ -0,0 +1,91 @@
+package aiwrapper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newChatServer responde como la API de OpenAI con content, o con status si no es 200
+func newChatServer(t *testing.T, status int, content string, calls *int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		if status != http.StatusOK {
+			http.Error(w, `{"error":{"message":"falla"}}`, status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"content":"` + content + `"},"finish_reason":"stop"}]}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCallWithFallback(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("AI_MAX_RETRIES", "0")
+	tests := []struct {
+		name                          string
+		primaryStatus, fallbackStatus int
+		fallbackProvider              string
+		wantText, wantModel, wantErr  string
+		wantPrimary, wantFallback     int
+	}{
+		{"responde el principal", http.StatusOK, http.StatusOK, "openai", "ls -la", "gpt-4o", "", 1, 0},
+		{"responde el respaldo", http.StatusUnauthorized, http.StatusOK, "openai", "ls -la respaldo", "gpt-4o-mini", "", 1, 1},
+		{"fallan ambos", http.StatusUnauthorized, http.StatusUnauthorized, "openai", "", "", "respaldo openai", 1, 1},
+		{"sin respaldo", http.StatusUnauthorized, http.StatusOK, "", "", "", "401", 1, 0},
+	}
+	for _, tt := range tests {
+		var primaryCalls, fallbackCalls int
+		primary := newChatServer(t, tt.primaryStatus, "ls -la", &primaryCalls)
+		fallback := newChatServer(t, tt.fallbackStatus, "ls -la respaldo", &fallbackCalls)
+		t.Setenv("AI_PROVIDER", "openai")
+		t.Setenv("AI_BASE_URL", primary.URL)
+		t.Setenv("AI_MODEL", "gpt-4o")
+		t.Setenv("AI_API_KEY", "sk-principal")
+		t.Setenv("AI_FALLBACK_PROVIDER", tt.fallbackProvider)
+		t.Setenv("AI_FALLBACK_BASE_URL", fallback.URL)
+		t.Setenv("AI_FALLBACK_MODEL", "gpt-4o-mini")
+		t.Setenv("AI_FALLBACK_API_KEY", "sk-respaldo")
+
+		response, config, err := callWithFallback(context.Background(), AIRequest{Prompt: "listar archivos"}, nil)
+		switch {
+		case tt.wantErr != "":
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: callWithFallback() error = %v, want %q", tt.name, err, tt.wantErr)
+			}
+		case err != nil:
+			t.Errorf("%s: callWithFallback() error = %v", tt.name, err)
+		case response.Text != tt.wantText || config.Model != tt.wantModel:
+			t.Errorf("%s: callWithFallback() = %q from %s, want %q from %s", tt.name, response.Text, config.Model, tt.wantText, tt.wantModel)
+		}
+		if primaryCalls != tt.wantPrimary || fallbackCalls != tt.wantFallback {
+			t.Errorf("%s: calls = %d primary, %d fallback, want %d, %d", tt.name, primaryCalls, fallbackCalls, tt.wantPrimary, tt.wantFallback)
+		}
+	}
+}
+
+func TestGetFallbackConfig(t *testing.T) {
+	t.Setenv("AI_FALLBACK_PROVIDER", "")
+	if _, ok := GetFallbackConfig(); ok {
+		t.Error("GetFallbackConfig() without AI_FALLBACK_PROVIDER ok = true")
+	}
+
+	t.Setenv("AI_FALLBACK_PROVIDER", "anthropic")
+	t.Setenv("AI_FALLBACK_BASE_URL", "")
+	t.Setenv("AI_FALLBACK_MODEL", "")
+	t.Setenv("AI_FALLBACK_API_KEY", "sk-ant")
+	t.Setenv("AI_BASE_URL", "https://principal.example")
+	config, ok := GetFallbackConfig()
+	if !ok || config.BaseURL != "https://api.anthropic.com/v1/messages" || config.Model != "claude-3-haiku-20240307" || config.APIKey != "sk-ant" {
+		t.Errorf("GetFallbackConfig() = %+v, %v, want the anthropic preset with its own key", config, ok)
+	}
+
+	t.Setenv("AI_FALLBACK_BASE_URL", "https://proxy.example/v1/messages")
+	if config, _ := GetFallbackConfig(); config.BaseURL != "https://proxy.example/v1/messages" {
+		t.Errorf("GetFallbackConfig().BaseURL = %q, want AI_FALLBACK_BASE_URL", config.BaseURL)
+	}
+}
@@ -0,0 +1,86 @@
+package aiwrapper
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// helpTimeout limita cuánto puede tardar `<binario> --help`
+const helpTimeout = 3 * time.Second
+
+// maxHelpOutput limita los bytes de ayuda agregados al prompt
+const maxHelpOutput = 2000
+
+// helpCache guarda la ayuda ya obtenida por binario durante la sesión
+var helpCache struct {
+	sync.Mutex
+	byBinary map[string]string
+}
+
+// commandBinary devuelve el binario principal del comando, saltando sudo y asignaciones VAR=valor
+func commandBinary(command string) string {
+	for _, field := range shellFields(command) {
+		if field == "sudo" || strings.Contains(field, "=") {
+			continue
+		}
+		if isShellOperator(field) {
+			return ""
+		}
+		return field
+	}
+	return ""
+}
+
+// binaryHelp ejecuta `<binario> --help` y devuelve su salida truncada, con caché por binario.
+// Solo acepta nombres simples que estén en el PATH, nunca rutas como ./script.
+func binaryHelp(binary string) string {
+	if strings.ContainsRune(binary, '/') {
+		return ""
+	}
+	helpCache.Lock()
+	help, ok := helpCache.byBinary[binary]
+	helpCache.Unlock()
+	if ok {
+		return help
+	}
+
+	if path, err := exec.LookPath(binary); err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), helpTimeout)
+		defer cancel()
+		// Muchas herramientas escriben la ayuda en stderr, así que se toman ambas salidas
+		output, _ := exec.CommandContext(ctx, path, "--help").CombinedOutput()
+		if len(output) > maxHelpOutput {
+			output = output[:maxHelpOutput]
+		}
+		help = strings.TrimSpace(strings.ToValidUTF8(string(output), ""))
+	}
+	helpCache.Lock()
+	if helpCache.byBinary == nil {
+		helpCache.byBinary = make(map[string]string)
+	}
+	helpCache.byBinary[binary] = help
+	helpCache.Unlock()
+	return help
+}
+
+// WithHelpContext agrega al prompt la ayuda del binario de command, que debe
+// ser un comando que el usuario ya aceptó y no solo uno generado por la IA
+func WithHelpContext(prompt, command string) string {
+	// No ejecutar binarios de comandos destructivos, aunque sea solo con --help
+	if dangerous, _ := IsDangerous(command); dangerous {
+		return prompt
+	}
+	binary := commandBinary(command)
+	if binary == "" {
+		return prompt
+	}
+	help := binaryHelp(binary)
+	if help == "" {
+		return prompt
+	}
+	return fmt.Sprintf("%s\n\nReferencia de `%s --help` (usa solo flags que existan):\n%s", prompt, binary, help)
+}
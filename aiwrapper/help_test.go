@@ -0,0 +1,48 @@
+package aiwrapper
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCommandBinary(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"ls -la", "ls"},
+		{"sudo apt install curl", "apt"},
+		{"LANG=C sort archivo.txt", "sort"},
+		{"./build.sh --release", "./build.sh"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := commandBinary(tt.command); got != tt.want {
+			t.Errorf("commandBinary(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestWithHelpContextSkipsPaths(t *testing.T) {
+	for _, command := range []string{"./build.sh --release", "/tmp/script", "rm -rf /"} {
+		if got := WithHelpContext("prompt", command); got != "prompt" {
+			t.Errorf("WithHelpContext(%q) = %q, want the prompt unchanged", command, got)
+		}
+	}
+}
+
+func TestBinaryHelpConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			binaryHelp("binario-que-no-existe")
+			warnOnce("test-concurrente", "")
+		}()
+	}
+	wg.Wait()
+	if help := binaryHelp("binario-que-no-existe"); help != "" {
+		t.Errorf("binaryHelp(missing) = %q, want empty", help)
+	}
+}
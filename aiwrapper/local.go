@@ -0,0 +1,61 @@
+package aiwrapper
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// defaultLlamaCLI es el binario de llama.cpp usado si AI_LLAMA_CLI no está definida
+const defaultLlamaCLI = "llama-cli"
+
+// localModelArgs arma los argumentos de llama-cli para una completion
+func localModelArgs(config AIConfig, request AIRequest, maxTokens int) []string {
+	system := request.System
+	if system == "" {
+		system = baseSystemPrompt()
+	}
+
+	args := []string{
+		"-m", config.Model,
+		"-n", strconv.Itoa(maxTokens),
+		"--temp", strconv.FormatFloat(config.Temperature, 'f', -1, 64),
+		"--no-display-prompt",
+		"-p", concatPrompt(system, request.History, request.Prompt),
+	}
+	// Flags extra para versiones de llama-cli que los necesiten (p. ej. -no-cnv)
+	return append(args, strings.Fields(getEnvOrDefault("AI_LLAMA_ARGS", ""))...)
+}
+
+// callLocalModel ejecuta llama-cli con el modelo .gguf de AI_MODEL_PATH y devuelve su stdout
+func callLocalModel(ctx context.Context, config AIConfig, request AIRequest) (AIResponse, error) {
+	if config.Model == "" {
+		return AIResponse{}, fmt.Errorf("el proveedor local requiere AI_MODEL_PATH con la ruta al modelo .gguf")
+	}
+
+	maxTokens := request.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = config.MaxTokens
+	}
+
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+	}
+
+	cli := getEnvOrDefault("AI_LLAMA_CLI", defaultLlamaCLI)
+	args := localModelArgs(config, request, maxTokens)
+	debugf("%s %q", cli, args)
+	output, err := exec.CommandContext(ctx, cli, args...).Output()
+	if err != nil {
+		return AIResponse{}, fmt.Errorf("error ejecutando %s: %v", cli, err)
+	}
+	debugf("respuesta: %s", output)
+
+	rawResponse := string(output)
+	tokens := recordTokenUsage(config.Provider, EstimateTokens(concatPrompt(request.System, request.History, request.Prompt))+EstimateTokens(rawResponse), -1)
+	return AIResponse{Text: rawResponse, TokensUsed: tokens}, nil
+}
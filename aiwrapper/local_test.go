@@ -0,0 +1,61 @@
+package aiwrapper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLocalModelArgs(t *testing.T) {
+	t.Setenv("AI_LLAMA_ARGS", "-no-cnv --threads 4")
+	config := AIConfig{Model: "/modelos/qwen.gguf", Temperature: 0.2}
+	request := AIRequest{System: "traduce a bash", Prompt: "listar archivos"}
+
+	args := localModelArgs(config, request, 256)
+	want := []string{
+		"-m", "/modelos/qwen.gguf",
+		"-n", "256",
+		"--temp", "0.2",
+		"--no-display-prompt",
+		"-p", concatPrompt("traduce a bash", nil, "listar archivos"),
+		"-no-cnv", "--threads", "4",
+	}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("localModelArgs() = %q, want %q", args, want)
+	}
+}
+
+func TestCallLocalModel(t *testing.T) {
+	dir := t.TempDir()
+	cli := filepath.Join(dir, "llama-cli")
+	// El script falso devuelve un comando y deja sus argumentos en args.txt
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > \"" + filepath.Join(dir, "args.txt") + "\"\necho 'ls -la'\n"
+	if err := os.WriteFile(cli, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AI_LLAMA_CLI", cli)
+	t.Setenv("AI_LLAMA_ARGS", "")
+
+	config := AIConfig{Provider: "local", Model: "/modelos/qwen.gguf", MaxTokens: 128}
+	response, err := callLocalModel(context.Background(), config, AIRequest{Prompt: "listar archivos"})
+	if err != nil {
+		t.Fatalf("callLocalModel() error = %v", err)
+	}
+	if strings.TrimSpace(response.Text) != "ls -la" || response.TokensUsed <= 0 {
+		t.Errorf("callLocalModel() = %+v, want ls -la with estimated tokens", response)
+	}
+	args, err := os.ReadFile(filepath.Join(dir, "args.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(args), "/modelos/qwen.gguf\n-n\n128\n") {
+		t.Errorf("llama-cli args = %q, want model path and token limit", args)
+	}
+
+	if _, err := callLocalModel(context.Background(), AIConfig{Provider: "local"}, AIRequest{Prompt: "x"}); err == nil || !strings.Contains(err.Error(), "AI_MODEL_PATH") {
+		t.Errorf("callLocalModel() without model error = %v, want AI_MODEL_PATH error", err)
+	}
+}
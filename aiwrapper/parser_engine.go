@@ -0,0 +1,1215 @@
+// Package aiwrapper traduce peticiones en lenguaje natural a comandos de shell
+// usando el proveedor de IA configurado por variables de entorno. El REPL neri
+// es un cliente de este paquete.
+package aiwrapper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"os/user"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Configuración de la API IA
+type AIConfig struct {
+	Provider      string
+	BaseURL       string
+	APIKey        string
+	Model         string
+	Timeout       time.Duration
+	MaxTokens     int
+	Temperature   float64
+	AuthStyle     string
+	PayloadStyle  string
+	APIVersion    string
+	VersionHeader string
+	VersionQuery  string
+}
+
+// defaultTimeout es el tiempo máximo de espera de una llamada a la API
+const defaultTimeout = 30 * time.Second
+
+// defaultTemperature es baja para que la generación de comandos sea determinista
+const defaultTemperature = 0.2
+
+// maxTemperature es la temperatura más alta aceptada (la de OpenAI y Gemini)
+const maxTemperature = 2.0
+
+// maxAnthropicTemperature es el límite de temperatura de Anthropic
+const maxAnthropicTemperature = 1.0
+
+// Petición a la API de IA
+type AIRequest struct {
+	System    string // vacío usa baseSystemPrompt()
+	Prompt    string
+	MaxTokens int        // 0 usa el límite por defecto del proveedor
+	History   []ChatTurn // turnos previos de la conversación, del más antiguo al más reciente
+	Stream    bool       // pedir la respuesta por partes (solo OpenAI y Ollama)
+	Config    *AIConfig  // nil usa GetAIConfig(); el respaldo pasa su propia configuración
+}
+
+// resolveConfig devuelve la configuración explícita del request o la del entorno
+func (r AIRequest) resolveConfig() AIConfig {
+	if r.Config != nil {
+		return *r.Config
+	}
+	return GetAIConfig()
+}
+
+// defaultSystemPrompt es la instrucción base enviada a todos los proveedores
+const defaultSystemPrompt = "Eres un asistente que convierte lenguaje natural a comandos de Unix/Linux. Responde SOLO con el comando, sin explicaciones."
+
+// baseSystemPrompt devuelve AI_SYSTEM_PROMPT si está definida, o la instrucción del sistema destino
+func baseSystemPrompt() string {
+	if getTargetOS() == targetWindows {
+		return getEnvOrDefault("AI_SYSTEM_PROMPT", windowsSystemPrompt)
+	}
+	return getEnvOrDefault("AI_SYSTEM_PROMPT", defaultSystemPrompt)
+}
+
+// templateInstruction pide al modelo marcadores en lugar de valores concretos
+const templateInstruction = " Si el comando necesita valores que el usuario no indicó (archivos, nombres, rutas), usa marcadores en mayúsculas como <ARCHIVO> o <DIRECTORIO>."
+
+// defaultMaxTokens es el límite conservador para modelos desconocidos
+const defaultMaxTokens = 100
+
+// modelMaxTokens define límites por familia de modelo (prefijo), en orden de prioridad
+var modelMaxTokens = []struct {
+	prefix    string
+	maxTokens int
+}{
+	{"codellama", 512},
+	{"deepseek-coder", 512},
+	{"qwen2.5-coder", 512},
+	{"starcoder", 512},
+	{"codegemma", 512},
+	{"gpt-4", 256},
+	{"gpt-3.5", 100},
+	{"gemini", 256},
+	{"claude", 256},
+	{"llama", 150},
+	{"mistral", 150},
+}
+
+// truncatedRetryFactor multiplica el límite de tokens al reintentar un comando truncado
+const truncatedRetryFactor = 4
+
+// teachMaxTokens deja espacio para el comando y sus anotaciones
+const teachMaxTokens = 400
+
+// teachSystemPrompt pide el comando junto con una explicación por flag
+const teachSystemPrompt = "Eres un instructor de Unix/Linux que convierte peticiones o comandos en un comando explicado. Responde con el comando en la primera línea y después una línea por cada flag u opción con el formato: <flag> = <explicación breve>. Sin texto adicional."
+
+// explainMaxTokens deja espacio para el comando y su explicación
+const explainMaxTokens = 300
+
+// explainSystemPrompt pide el comando seguido de una explicación breve
+const explainSystemPrompt = "Eres un asistente que convierte peticiones en lenguaje natural a comandos de Unix/Linux. Responde con el comando en la primera línea y debajo una explicación breve (2-3 frases) de lo que hace. Sin bloques de código ni texto adicional."
+
+// fixSystemPrompt pide corregir un comando de shell que falló
+const fixSystemPrompt = "Eres un asistente que corrige comandos de Unix/Linux que fallaron. Responde SOLO con el comando corregido, sin explicaciones."
+
+// Anotación de un flag en modo teach
+type FlagAnnotation struct {
+	Flag    string
+	Meaning string
+}
+
+// Respuesta de OpenAI
+type OpenAIResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+			Refusal string `json:"refusal"` // motivo cuando el modelo se niega a responder
+		} `json:"message"`
+		// Algunos servidores compatibles responden en formato completions
+		Text         string `json:"text"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// Respuesta de Gemini
+type GeminiResponse struct {
+	Candidates []struct {
+		Content struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"content"`
+		FinishReason string `json:"finishReason"`
+	} `json:"candidates"`
+	// Sin candidatos, blockReason indica por qué se bloqueó el prompt
+	PromptFeedback *struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+	UsageMetadata *struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+		TotalTokenCount      int `json:"totalTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+// Respuesta de Anthropic
+type AnthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      *struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// Respuesta de Ollama
+type OllamaResponse struct {
+	Response        string `json:"response"`
+	DoneReason      string `json:"done_reason"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+}
+
+// httpTransport es el transporte de las llamadas a la IA. Las pruebas pueden
+// reemplazarlo (p. ej. por el cliente de un httptest.Server) para ejecutar
+// toda la traducción sin red.
+var httpTransport http.RoundTripper = http.DefaultTransport
+
+// SetHTTPTransport reemplaza el transporte de las llamadas a la IA (nil vuelve
+// al de por defecto), p. ej. para usar un proxy propio o un httptest.Server
+func SetHTTPTransport(transport http.RoundTripper) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	httpTransport = transport
+}
+
+// maxRedirects limita las redirecciones seguidas en una llamada a la IA
+const maxRedirects = 10
+
+// newHTTPClient crea el cliente HTTP con el timeout configurado
+func newHTTPClient(config AIConfig) *http.Client {
+	return &http.Client{Transport: httpTransport, Timeout: config.Timeout, CheckRedirect: checkRedirect}
+}
+
+// checkRedirect sigue solo redirecciones al mismo host, conservando los headers
+// de autenticación. Las redirecciones a otro host fallan con un error claro en
+// lugar de un 401 por haber perdido la API key.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	original := via[0]
+	if !GetEnvBool("AI_FOLLOW_REDIRECTS", true) {
+		return fmt.Errorf("el endpoint redirige a %s y AI_FOLLOW_REDIRECTS está desactivado; configura AI_BASE_URL con la URL final", req.URL.Redacted())
+	}
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("demasiadas redirecciones desde %s", original.URL.Redacted())
+	}
+	if req.URL.Host != original.URL.Host {
+		return fmt.Errorf("el endpoint redirige a otro host (%s → %s); no se reenvía la API key, configura AI_BASE_URL con la URL final", original.URL.Host, req.URL.Host)
+	}
+	if req.Method != original.Method {
+		// 301/302/303 convierten el POST en GET y el body se pierde
+		return fmt.Errorf("la redirección a %s cambia %s por %s; configura AI_BASE_URL con la URL final", req.URL.Redacted(), original.Method, req.Method)
+	}
+
+	for _, header := range []string{"Authorization", "x-api-key", "x-goog-api-key", "api-key"} {
+		if value := original.Header.Get(header); value != "" {
+			req.Header.Set(header, value)
+		}
+	}
+	return nil
+}
+
+// sessionRequests cuenta las peticiones HTTP enviadas a la IA en la sesión por
+// host. Solo se guarda el host: ni la ruta, ni la query ni los headers.
+var sessionRequests struct {
+	sync.Mutex
+	byHost map[string]int
+}
+
+// Peticiones enviadas a un host en la sesión
+type HostRequests struct {
+	Host     string
+	Requests int
+}
+
+// recordRequest registra una petición enviada a la IA
+func recordRequest(u *url.URL) {
+	sessionRequests.Lock()
+	defer sessionRequests.Unlock()
+	if sessionRequests.byHost == nil {
+		sessionRequests.byHost = make(map[string]int)
+	}
+	sessionRequests.byHost[u.Host]++
+}
+
+// RequestCount devuelve cuántas peticiones HTTP se enviaron a la IA en la sesión
+func RequestCount() int64 {
+	sessionRequests.Lock()
+	defer sessionRequests.Unlock()
+	var total int64
+	for _, count := range sessionRequests.byHost {
+		total += int64(count)
+	}
+	return total
+}
+
+// RequestsByHost devuelve las peticiones de la sesión por host, en orden alfabético
+func RequestsByHost() []HostRequests {
+	sessionRequests.Lock()
+	defer sessionRequests.Unlock()
+	hosts := make([]HostRequests, 0, len(sessionRequests.byHost))
+	for host, count := range sessionRequests.byHost {
+		hosts = append(hosts, HostRequests{Host: host, Requests: count})
+	}
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Host < hosts[j].Host })
+	return hosts
+}
+
+// GetAIConfig obtiene la configuración desde variables de entorno y el .neri del proyecto
+func GetAIConfig() AIConfig {
+	// Prioridad: variables de entorno, luego .neri, luego config.json, luego los valores por defecto
+	file := loadConfigFile()
+	project := mergeSettings(loadProjectSettings(), file.settings())
+	config := AIConfig{
+		Provider: getSetting(project, "AI_PROVIDER", "ollama"),
+		BaseURL:  "",
+		APIKey:   "",
+		Model:    "",
+	}
+
+	if preset, ok := providerPresets[config.Provider]; ok {
+		config.BaseURL = getSetting(project, "AI_BASE_URL", preset.BaseURL)
+		config.Model = getSetting(project, "AI_MODEL", preset.DefaultModel)
+		config.AuthStyle = preset.AuthStyle
+		config.PayloadStyle = preset.PayloadStyle
+		config.APIVersion = getEnvOrDefault("AI_API_VERSION", preset.DefaultVersion)
+		config.VersionHeader = preset.VersionHeader
+		config.VersionQuery = preset.VersionQuery
+		if preset.PayloadStyle == payloadLocal {
+			// El "modelo" del proveedor local es la ruta al archivo .gguf
+			config.Model = getSetting(project, "AI_MODEL_PATH", "")
+		}
+		if preset.DeploymentPath != "" {
+			// Azure: {base}/openai/deployments/{deployment}/chat/completions?api-version=...
+			config.Model = getSetting(project, "AI_AZURE_DEPLOYMENT", config.Model)
+			config.BaseURL = strings.TrimRight(config.BaseURL, "/") + fmt.Sprintf(preset.DeploymentPath, config.Model)
+			config.APIVersion = getEnvOrDefault("AI_AZURE_API_VERSION", config.APIVersion)
+		}
+		if preset.AuthStyle != AuthNone {
+			config.APIKey = getEnvOrDefault("AI_API_KEY", file.APIKey)
+		}
+	}
+	config.Timeout = getProviderTimeout(config.Provider, file.timeout(defaultTimeout))
+	config.MaxTokens = getMaxTokens(config.Model)
+	config.Temperature = getTemperature()
+
+	return config
+}
+
+// getMaxTokens usa AI_MAX_TOKENS si es válido, si no el límite de la familia del modelo
+func getMaxTokens(model string) int {
+	if value, ok := configuredMaxTokens(); ok {
+		return value
+	}
+	return defaultMaxTokensForModel(model)
+}
+
+// configuredMaxTokens lee AI_MAX_TOKENS; un valor inválido se ignora con una advertencia
+func configuredMaxTokens() (int, bool) {
+	value := os.Getenv("AI_MAX_TOKENS")
+	if value == "" {
+		return 0, false
+	}
+	tokens, err := strconv.Atoi(value)
+	if err != nil || tokens <= 0 {
+		warnOnce("AI_MAX_TOKENS", "⚠️  AI_MAX_TOKENS inválido (%q), debe ser un entero positivo\n", value)
+		return 0, false
+	}
+	return tokens, true
+}
+
+// getTemperature lee AI_TEMPERATURE (0 a 2); un valor inválido se ignora con una advertencia
+func getTemperature() float64 {
+	value := os.Getenv("AI_TEMPERATURE")
+	if value == "" {
+		return defaultTemperature
+	}
+	temperature, err := strconv.ParseFloat(value, 64)
+	if err != nil || temperature < 0 || temperature > maxTemperature {
+		warnOnce("AI_TEMPERATURE", "⚠️  AI_TEMPERATURE inválida (%q), debe estar entre 0 y %g; se usa %g\n", value, maxTemperature, defaultTemperature)
+		return defaultTemperature
+	}
+	return temperature
+}
+
+// defaultMaxTokensForModel obtiene el límite por defecto según la familia del modelo
+func defaultMaxTokensForModel(model string) int {
+	model = strings.ToLower(model)
+	for _, family := range modelMaxTokens {
+		if strings.HasPrefix(model, family.prefix) {
+			return family.maxTokens
+		}
+	}
+	return defaultMaxTokens
+}
+
+// getProviderTimeout resuelve el timeout: AI_<PROVEEDOR>_TIMEOUT, luego
+// AI_TIMEOUT_SECONDS (o su alias AI_TIMEOUT), luego defaultValue.
+// Un valor de 0 desactiva el timeout; uno inválido se ignora con una advertencia.
+func getProviderTimeout(provider string, defaultValue time.Duration) time.Duration {
+	providerKey := "AI_" + strings.ToUpper(strings.ReplaceAll(provider, "-", "_")) + "_TIMEOUT"
+	for _, key := range []string{providerKey, "AI_TIMEOUT_SECONDS", "AI_TIMEOUT"} {
+		value := os.Getenv(key)
+		if value == "" {
+			continue
+		}
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 0 {
+			warnOnce(key, "⚠️  %s inválido (%q), se usa el valor por defecto de %v\n", key, value, defaultValue)
+			continue
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultValue
+}
+
+// getEnvOrDefault obtiene variable de entorno o valor por defecto
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// GetEnvBool obtiene una variable de entorno booleana o valor por defecto
+func GetEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// addOpenAIAttribution agrega los campos user y metadata al payload de OpenAI
+func addOpenAIAttribution(payload map[string]interface{}) error {
+	if userID := os.Getenv("AI_USER_ID"); userID != "" {
+		payload["user"] = userID
+	}
+	if rawMetadata := os.Getenv("AI_REQUEST_METADATA"); rawMetadata != "" {
+		var metadata map[string]string
+		if err := json.Unmarshal([]byte(rawMetadata), &metadata); err != nil {
+			return fmt.Errorf("AI_REQUEST_METADATA debe ser un objeto JSON de cadenas: %v", err)
+		}
+		payload["metadata"] = metadata
+	}
+	return nil
+}
+
+// buildAPIRequest arma la petición HTTP (payload, endpoint y headers) para el proveedor configurado
+func buildAPIRequest(config AIConfig, request AIRequest) (*http.Request, []byte, error) {
+	if err := validateConfig(config); err != nil {
+		return nil, nil, err
+	}
+	prompt := request.Prompt
+	system := request.System
+	if system == "" {
+		system = baseSystemPrompt()
+	}
+
+	maxTokens := request.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = config.MaxTokens
+	}
+	// Gemini y Ollama tienen su propio límite por defecto: solo se fija si se pidió uno
+	_, maxTokensConfigured := configuredMaxTokens()
+	limitTokens := request.MaxTokens > 0 || maxTokensConfigured
+
+	var payload interface{}
+	var endpoint string
+
+	switch config.PayloadStyle {
+	case payloadOpenAI:
+		openAIPayload := map[string]interface{}{
+			"model":       config.Model,
+			"messages":    append([]map[string]string{{"role": "system", "content": system}}, chatMessages(request.History, prompt)...),
+			"max_tokens":  maxTokens,
+			"temperature": config.Temperature,
+		}
+		if request.Stream {
+			openAIPayload["stream"] = true
+		}
+		if err := addOpenAIAttribution(openAIPayload); err != nil {
+			return nil, nil, err
+		}
+		payload = openAIPayload
+		endpoint = config.BaseURL
+	case payloadGemini:
+		endpoint = fmt.Sprintf("%s/%s:generateContent", config.BaseURL, config.Model)
+		geminiPayload := map[string]interface{}{
+			"contents": []map[string]interface{}{
+				{
+					"parts": []map[string]string{
+						{"text": concatPrompt(system, request.History, prompt)},
+					},
+				},
+			},
+		}
+		generationConfig := map[string]interface{}{"temperature": config.Temperature}
+		if limitTokens {
+			generationConfig["maxOutputTokens"] = maxTokens
+		}
+		geminiPayload["generationConfig"] = generationConfig
+		payload = geminiPayload
+	case payloadOllama:
+		ollamaPayload := map[string]interface{}{
+			"model":  config.Model,
+			"prompt": concatPrompt(system, request.History, prompt),
+			"stream": request.Stream,
+		}
+		options := map[string]interface{}{"temperature": config.Temperature}
+		if limitTokens {
+			options["num_predict"] = maxTokens
+		}
+		ollamaPayload["options"] = options
+		payload = ollamaPayload
+		endpoint = config.BaseURL
+	case payloadAnthropic:
+		payload = map[string]interface{}{
+			"model":       config.Model,
+			"system":      system,
+			"max_tokens":  maxTokens,
+			"temperature": math.Min(config.Temperature, maxAnthropicTemperature),
+			"messages":    chatMessages(request.History, prompt),
+		}
+		endpoint = config.BaseURL
+	default:
+		return nil, nil, fmt.Errorf("proveedor no soportado: %s", config.Provider)
+	}
+	// Serializar payload
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error serializando payload: %v", err)
+	}
+
+	// Crear request HTTP
+	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creando request: %v", err)
+	}
+
+	// Setear headers
+	req.Header.Set("Content-Type", "application/json")
+	if config.APIKey != "" {
+		switch config.AuthStyle {
+		case authBearer, AuthOptional:
+			req.Header.Set("Authorization", "Bearer "+config.APIKey)
+		case authXAPIKey:
+			req.Header.Set("x-api-key", config.APIKey)
+		case authGoogAPIKey:
+			req.Header.Set("x-goog-api-key", config.APIKey)
+		case authAPIKey:
+			req.Header.Set("api-key", config.APIKey)
+		}
+	}
+	if config.APIVersion != "" {
+		applyAPIVersion(req, config)
+	}
+	if config.Provider == "openai" {
+		// Atribución de facturación para equipos con varios proyectos
+		if org := os.Getenv("AI_OPENAI_ORG"); org != "" {
+			req.Header.Set("OpenAI-Organization", org)
+		}
+		if project := os.Getenv("AI_OPENAI_PROJECT"); project != "" {
+			req.Header.Set("OpenAI-Project", project)
+		}
+	}
+
+	return req, jsonData, nil
+}
+
+// DumpRequest describe la petición que se enviaría para el prompt con los turnos
+// previos de history, sin enviarla. La API key se enmascara en la URL y en los headers.
+func DumpRequest(userText string, history []ChatTurn) (string, error) {
+	config := GetAIConfig()
+	request := AIRequest{System: translationSystemPrompt(), Prompt: userText, History: history}
+	if config.PayloadStyle == payloadLocal {
+		cli := getEnvOrDefault("AI_LLAMA_CLI", defaultLlamaCLI)
+		args := localModelArgs(config, request, config.MaxTokens)
+		return fmt.Sprintf("%s %q", cli, args), nil
+	}
+	req, body, err := buildAPIRequest(config, request)
+	if err != nil {
+		return "", err
+	}
+
+	mask := func(s string) string {
+		if len(config.APIKey) < minRedactLength {
+			return Redact(s)
+		}
+		return Redact(strings.ReplaceAll(s, config.APIKey, "****"))
+	}
+
+	var dump strings.Builder
+	fmt.Fprintf(&dump, "%s %s\n", req.Method, mask(req.URL.String()))
+	headerNames := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+	for _, name := range headerNames {
+		fmt.Fprintf(&dump, "%s: %s\n", name, mask(req.Header.Get(name)))
+	}
+
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, body, "", "  "); err != nil {
+		pretty.Write(body)
+	}
+	dump.WriteString("\n")
+	dump.WriteString(mask(pretty.String()))
+	return dump.String(), nil
+}
+
+// applyAPIVersion coloca la versión de la API como header o parámetro según el proveedor
+func applyAPIVersion(req *http.Request, config AIConfig) {
+	switch {
+	case config.VersionHeader != "":
+		req.Header.Set(config.VersionHeader, config.APIVersion)
+	case config.VersionQuery != "":
+		query := req.URL.Query()
+		query.Set(config.VersionQuery, config.APIVersion)
+		req.URL.RawQuery = query.Encode()
+	}
+}
+
+// Respuesta de la IA junto con el motivo de finalización informado por el proveedor
+type AIResponse struct {
+	Text         string
+	FinishReason string // vacío si el proveedor no lo informa
+	TokensUsed   int    // reportados por el proveedor o, si no los informa, estimados
+
+	// Desglose informado por el proveedor; 0 si no lo informa
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// normalFinishReasons son los motivos de finalización de una respuesta completa
+var normalFinishReasons = map[string]bool{
+	"stop":          true, // OpenAI, Gemini (STOP), Ollama
+	"end_turn":      true, // Anthropic
+	"stop_sequence": true, // Anthropic
+}
+
+// isNormalFinish indica si la respuesta terminó normalmente; un motivo desconocido no cuenta como error
+func isNormalFinish(reason string) bool {
+	return reason == "" || normalFinishReasons[strings.ToLower(reason)]
+}
+
+// errEmptyResponse indica que el proveedor respondió sin error pero sin texto
+var errEmptyResponse = errors.New("la IA devolvió una respuesta vacía")
+
+// emptyResponseReasons explica los motivos de finalización que dejan la respuesta vacía
+var emptyResponseReasons = map[string]string{
+	"content_filter":     "filtro de contenido",            // OpenAI, Azure
+	"safety":             "filtro de contenido",            // Gemini
+	"prohibited_content": "filtro de contenido",            // Gemini
+	"blocklist":          "filtro de contenido",            // Gemini
+	"length":             "se alcanzó el límite de tokens", // OpenAI
+	"max_tokens":         "se alcanzó el límite de tokens", // Anthropic, Gemini
+}
+
+// emptyResponseError describe una respuesta vacía según el motivo de finalización
+// o el rechazo informado por el proveedor
+func emptyResponseError(finishReason, refusal string) error {
+	if refusal != "" {
+		return fmt.Errorf("%w, el modelo rechazó la petición: %s", errEmptyResponse, refusal)
+	}
+	if reason, ok := emptyResponseReasons[strings.ToLower(finishReason)]; ok {
+		return fmt.Errorf("%w (%s, finish_reason: %s)", errEmptyResponse, reason, finishReason)
+	}
+	if finishReason != "" {
+		return fmt.Errorf("%w (posible filtro de contenido, finish_reason: %s)", errEmptyResponse, finishReason)
+	}
+	return fmt.Errorf("%w (posible filtro de contenido)", errEmptyResponse)
+}
+
+// aiCallError agrega la sugerencia de revisar la conexión o la API key, salvo
+// que la IA haya respondido con una respuesta vacía
+func aiCallError(err error) error {
+	if errors.Is(err, errEmptyResponse) {
+		return err
+	}
+	return fmt.Errorf("no se pudo conectar con la IA (verifica tu conexión o API key): %v", err)
+}
+
+// CallAIAPI realiza la llamada HTTP a la API de IA y devuelve solo el texto
+func CallAIAPI(ctx context.Context, request AIRequest) (string, error) {
+	response, err := callAI(ctx, request)
+	return response.Text, err
+}
+
+// callAI realiza la llamada a la IA; los errores nunca incluyen la API key
+func callAI(ctx context.Context, request AIRequest) (AIResponse, error) {
+	response, err := sendAIRequest(ctx, request)
+	return response, redactError(err)
+}
+
+// sendAIRequest realiza la llamada HTTP a la API de IA
+func sendAIRequest(ctx context.Context, request AIRequest) (AIResponse, error) {
+	config := request.resolveConfig()
+	if config.PayloadStyle == payloadLocal {
+		return callLocalModel(ctx, config, request)
+	}
+	// Ejecutar request
+	resp, err := sendWithRetry(ctx, config, request)
+	if err != nil {
+		return AIResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	// Leer respuesta
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return AIResponse{}, fmt.Errorf("error leyendo respuesta: %v", err)
+	}
+	debugf("respuesta: %s", body)
+
+	// Manejar errores HTTP
+	if resp.StatusCode >= 400 {
+		return AIResponse{}, fmt.Errorf("error HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	// Parsear respuesta según provider; reportedTokens < 0 si no se reporta uso
+	var rawResponse, finishReason, refusal string
+	var response AIResponse
+	reportedTokens := -1
+	switch config.PayloadStyle {
+	case payloadOpenAI:
+		var openAIResp OpenAIResponse
+		if err := json.Unmarshal(body, &openAIResp); err != nil {
+			return AIResponse{}, fmt.Errorf("error parseando respuesta OpenAI: %v", err)
+		}
+		if len(openAIResp.Choices) > 0 {
+			rawResponse = openAIResp.Choices[0].Message.Content
+			if rawResponse == "" {
+				rawResponse = openAIResp.Choices[0].Text
+			}
+			finishReason = openAIResp.Choices[0].FinishReason
+			refusal = openAIResp.Choices[0].Message.Refusal
+		}
+		if openAIResp.Usage != nil {
+			reportedTokens = openAIResp.Usage.TotalTokens
+			response.PromptTokens = openAIResp.Usage.PromptTokens
+			response.CompletionTokens = openAIResp.Usage.CompletionTokens
+		}
+	case payloadGemini:
+		var geminiResp GeminiResponse
+		if err := json.Unmarshal(body, &geminiResp); err != nil {
+			return AIResponse{}, fmt.Errorf("error parseando respuesta Gemini: %v", err)
+		}
+		if len(geminiResp.Candidates) > 0 {
+			if len(geminiResp.Candidates[0].Content.Parts) > 0 {
+				rawResponse = geminiResp.Candidates[0].Content.Parts[0].Text
+			}
+			finishReason = geminiResp.Candidates[0].FinishReason
+		} else if geminiResp.PromptFeedback != nil {
+			finishReason = geminiResp.PromptFeedback.BlockReason
+		}
+		if geminiResp.UsageMetadata != nil {
+			reportedTokens = geminiResp.UsageMetadata.TotalTokenCount
+			response.PromptTokens = geminiResp.UsageMetadata.PromptTokenCount
+			response.CompletionTokens = geminiResp.UsageMetadata.CandidatesTokenCount
+		}
+	case payloadAnthropic:
+		var anthropicResp AnthropicResponse
+		if err := json.Unmarshal(body, &anthropicResp); err != nil {
+			return AIResponse{}, fmt.Errorf("error parseando respuesta Anthropic: %v", err)
+		}
+		var text strings.Builder
+		for _, block := range anthropicResp.Content {
+			if block.Type == "text" {
+				text.WriteString(block.Text)
+			}
+		}
+		rawResponse = text.String()
+		finishReason = anthropicResp.StopReason
+		if anthropicResp.Usage != nil {
+			reportedTokens = anthropicResp.Usage.InputTokens + anthropicResp.Usage.OutputTokens
+			response.PromptTokens = anthropicResp.Usage.InputTokens
+			response.CompletionTokens = anthropicResp.Usage.OutputTokens
+		}
+	case payloadOllama:
+		var ollamaResp OllamaResponse
+		if err := json.Unmarshal(body, &ollamaResp); err != nil {
+			return AIResponse{}, fmt.Errorf("error parseando respuesta Ollama: %v", err)
+		}
+		rawResponse = ollamaResp.Response
+		finishReason = ollamaResp.DoneReason
+		if ollamaResp.EvalCount > 0 {
+			reportedTokens = ollamaResp.PromptEvalCount + ollamaResp.EvalCount
+			response.PromptTokens = ollamaResp.PromptEvalCount
+			response.CompletionTokens = ollamaResp.EvalCount
+		}
+	}
+
+	system := request.System
+	if system == "" {
+		system = baseSystemPrompt()
+	}
+	response.Text, response.FinishReason = rawResponse, finishReason
+	response.TokensUsed = recordTokenUsage(config.Provider, EstimateTokens(concatPrompt(system, request.History, request.Prompt))+EstimateTokens(rawResponse), reportedTokens)
+	if strings.TrimSpace(rawResponse) == "" {
+		return response, emptyResponseError(finishReason, refusal)
+	}
+	return response, nil
+}
+
+// ansiRegex reconoce secuencias de escape ANSI (colores, movimientos de cursor, OSC)
+var ansiRegex = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+// stripANSI elimina las secuencias de escape ANSI de la respuesta IA
+func stripANSI(s string) string {
+	return ansiRegex.ReplaceAllString(s, "")
+}
+
+// cleanResponse prepara la respuesta cruda antes de la sanitización
+func cleanResponse(raw string) string {
+	// Reemplazar secuencias UTF-8 inválidas de modelos locales o proxies
+	if GetEnvBool("AI_SANITIZE_UTF8", true) {
+		raw = strings.ToValidUTF8(raw, "\uFFFD")
+	}
+
+	// Quitar códigos ANSI que algunos modelos incluyen en la salida
+	if GetEnvBool("AI_STRIP_ANSI", true) {
+		raw = stripANSI(raw)
+	}
+	return raw
+}
+
+// Comando extraído junto con el lenguaje del bloque de código, si no es shell
+type CommandInfo struct {
+	Command  string
+	Language string // vacío para shell; p. ej. "python" si el bloque no es de shell
+}
+
+// shellLanguages son las etiquetas de bloque que se consideran comandos de shell
+var shellLanguages = map[string]bool{
+	"":             true,
+	"bash":         true,
+	"sh":           true,
+	"zsh":          true,
+	"ksh":          true,
+	"fish":         true,
+	"shell":        true,
+	"console":      true,
+	"shellsession": true,
+}
+
+// SanitizeCommand limpia y extrae el comando ejecutable de la respuesta IA
+func SanitizeCommand(raw string) string {
+	return ParseCommandInfo(raw).Command
+}
+
+// ParseCommandInfo extrae el comando y el lenguaje del bloque de código de la respuesta IA
+func ParseCommandInfo(raw string) CommandInfo {
+	// Trim espacios
+	raw = strings.TrimSpace(raw)
+
+	// Caso 1: Bloque de código con triple backticks y etiqueta de lenguaje opcional
+	backtickRegex := regexp.MustCompile("(?s)```(?:([\\w+-]+)[ \\t]*\n)?(.*?)```")
+	matches := backtickRegex.FindStringSubmatch(raw)
+	if len(matches) > 2 {
+		language := strings.ToLower(matches[1])
+		content := strings.TrimSpace(matches[2])
+		if !isShellLanguage(language) {
+			// Código de otro lenguaje: devolver el bloque completo marcado
+			return CommandInfo{Command: content, Language: language}
+		}
+		return CommandInfo{Command: stripListMarker(getFirstNonEmptyLine(content))}
+	}
+
+	// Caso 2: Inline code con backticks
+	inlineRegex := regexp.MustCompile("`([^`]+)`")
+	matches = inlineRegex.FindStringSubmatch(raw)
+	if len(matches) > 1 {
+		command := strings.TrimSpace(matches[1])
+		return CommandInfo{Command: command}
+	}
+
+	// Caso 3: Primera línea que parece comando
+	lines := strings.Split(raw, "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line != "" && !looksLikeExplanation(line) {
+			line = stripListMarker(line)
+			// Limpiar prompts tipo $, neri>, Emiliano>, PS>, PS C:\Users>
+			line = regexp.MustCompile(`^\$|^\s*neri>|^\s*Emiliano>|^\s*PS( [^>]*)?>`).ReplaceAllString(line, "")
+			return CommandInfo{Command: strings.TrimSpace(line)}
+		}
+	}
+
+	// Solo hay explicación: no hay comando que devolver
+	return CommandInfo{}
+}
+
+// listMarkerRegex reconoce viñetas y numeración de markdown al inicio de la línea
+// ("1. ", "2) ", "- ", "* "); exige un espacio para no tocar flags como -la
+var listMarkerRegex = regexp.MustCompile(`^(\d+[.)]|[-*•])\s+`)
+
+// stripListMarker quita la viñeta o numeración inicial de una línea
+func stripListMarker(line string) string {
+	return strings.TrimSpace(listMarkerRegex.ReplaceAllString(strings.TrimSpace(line), ""))
+}
+
+// getFirstNonEmptyLine obtiene la primera línea no vacía
+func getFirstNonEmptyLine(text string) string {
+	lines := strings.Split(text, "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// looksLikeExplanation verifica si una línea parece explicación
+func looksLikeExplanation(line string) bool {
+	explanationPatterns := []string{
+		`^para`,
+		`^usa`,
+		`^este`,
+		`^el comando`,
+		`^la respuesta`,
+		`^you can`,
+		`^this will`,
+		`^use`,
+		`^the command`,
+	}
+
+	for _, pattern := range explanationPatterns {
+		if matched, _ := regexp.MatchString(pattern, strings.ToLower(line)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// EstimateTokens estima los tokens de un texto (~4 caracteres por token)
+func EstimateTokens(text string) int {
+	return (len([]rune(text)) + 3) / 4
+}
+
+// placeholderRegex reconoce marcadores tipo <NOMBRE> en un comando plantilla
+var placeholderRegex = regexp.MustCompile(`<([A-Z][A-Z0-9_]*)>`)
+
+// ExtractPlaceholders obtiene los nombres de marcadores sin repetir, en orden de aparición
+func ExtractPlaceholders(cmd string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, match := range placeholderRegex.FindAllStringSubmatch(cmd, -1) {
+		name := match[1]
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// FillPlaceholders reemplaza cada <NOMBRE> por su valor; los marcadores sin valor se conservan
+func FillPlaceholders(cmd string, values map[string]string) string {
+	return placeholderRegex.ReplaceAllStringFunc(cmd, func(match string) string {
+		name := match[1 : len(match)-1]
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return match
+	})
+}
+
+// annotationRegex reconoce líneas "<flag> = <explicación>", con viñeta opcional
+var annotationRegex = regexp.MustCompile("^(?:[-*]\\s+)?`?(\\S+?)`?\\s+=\\s+(.+)$")
+
+// parseTeachResponse separa el comando y las anotaciones por flag de la respuesta
+func parseTeachResponse(raw string) (string, []FlagAnnotation) {
+	var commandLines []string
+	var annotations []FlagAnnotation
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "```") {
+			continue
+		}
+		if matches := annotationRegex.FindStringSubmatch(line); matches != nil {
+			annotations = append(annotations, FlagAnnotation{Flag: matches[1], Meaning: strings.TrimSpace(matches[2])})
+			continue
+		}
+		if len(annotations) == 0 {
+			commandLines = append(commandLines, line)
+		}
+	}
+	return SanitizeCommand(strings.Join(commandLines, "\n")), annotations
+}
+
+// TeachCommand genera un comando con la explicación de cada flag
+func TeachCommand(ctx context.Context, userText string) (string, []FlagAnnotation, error) {
+	rawResponse, err := CallAIAPI(ctx, AIRequest{System: teachSystemPrompt, Prompt: userText, MaxTokens: teachMaxTokens})
+	if err != nil {
+		return "", nil, aiCallError(err)
+	}
+
+	command, annotations := parseTeachResponse(cleanResponse(rawResponse))
+	if command == "" {
+		return "", nil, fmt.Errorf("la IA no pudo generar un comando válido")
+	}
+	return command, annotations, nil
+}
+
+// parseExplainResponse separa el comando (primera línea) de la explicación que le sigue
+func parseExplainResponse(raw string) (string, string) {
+	var command string
+	var explanation []string
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "```") {
+			continue
+		}
+		if command == "" {
+			command = line
+			continue
+		}
+		explanation = append(explanation, line)
+	}
+	return SanitizeCommand(command), strings.TrimSpace(strings.Join(explanation, "\n"))
+}
+
+// ExplainCommand genera un comando junto con una explicación breve de lo que hace
+func ExplainCommand(ctx context.Context, userText string) (*TranslationResult, error) {
+	config := GetAIConfig()
+	response, err := callAI(ctx, AIRequest{System: explainSystemPrompt, Prompt: userText, MaxTokens: explainMaxTokens, Config: &config})
+	if err != nil {
+		return nil, aiCallError(err)
+	}
+
+	result := &TranslationResult{
+		Raw:          cleanResponse(response.Text),
+		Provider:     config.Provider,
+		Model:        config.Model,
+		TokensUsed:   response.TokensUsed,
+		FinishReason: response.FinishReason,
+
+		PromptTokens:     response.PromptTokens,
+		CompletionTokens: response.CompletionTokens,
+	}
+	result.Command, result.Explanation = parseExplainResponse(result.Raw)
+	if result.Command == "" {
+		return nil, fmt.Errorf("la IA no pudo generar un comando válido")
+	}
+	return result, nil
+}
+
+// FixCommand pide a la IA una versión corregida de un comando que falló
+func FixCommand(ctx context.Context, command, errorOutput string) (string, string, error) {
+	prompt := fmt.Sprintf("Comando: %s", command)
+	if errorOutput != "" {
+		prompt += fmt.Sprintf("\nError: %s", errorOutput)
+	}
+
+	rawResponse, err := CallAIAPI(ctx, AIRequest{System: fixSystemPrompt, Prompt: prompt})
+	if err != nil {
+		return "", "", aiCallError(err)
+	}
+	rawResponse = cleanResponse(rawResponse)
+
+	fixedCommand := SanitizeCommand(rawResponse)
+	if fixedCommand == "" {
+		return rawResponse, "", fmt.Errorf("la IA no pudo generar un comando válido")
+	}
+	return rawResponse, fixedCommand, nil
+}
+
+// LooksTruncated verifica si un comando parece cortado a mitad de token
+func LooksTruncated(cmd string) bool {
+	cmd = strings.TrimSpace(cmd)
+	if cmd == "" {
+		return false
+	}
+
+	// Comillas sin cerrar
+	var quote rune
+	escaped := false
+	for _, r := range cmd {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		}
+	}
+	if quote != 0 {
+		return true
+	}
+
+	// Operadores al final que esperan otro comando o argumento
+	trailingOperators := []string{"&&", "||", "|", "\\", ">", "<"}
+	for _, op := range trailingOperators {
+		if strings.HasSuffix(cmd, op) {
+			return true
+		}
+	}
+	return false
+}
+
+// translationSystemPrompt arma el system prompt de traducción según la configuración
+func translationSystemPrompt() string {
+	system := baseSystemPrompt()
+	if GetEnvBool("AI_TEMPLATE_MODE", false) {
+		system += templateInstruction
+	}
+	if GetEnvBool("AI_INCLUDE_USER", false) {
+		system += userContext()
+	}
+	system += preferredToolsHint(os.Getenv("AI_PREFERRED_TOOLS"))
+	system += domainHint(GetDomain())
+	return system
+}
+
+// preferredToolsHint convierte "search=rg,json=jq" en una preferencia para el modelo
+func preferredToolsHint(spec string) string {
+	var preferences []string
+	for _, entry := range strings.Split(spec, ",") {
+		task, tool, ok := strings.Cut(entry, "=")
+		task, tool = strings.TrimSpace(task), strings.TrimSpace(tool)
+		if !ok || task == "" || tool == "" {
+			continue
+		}
+		preferences = append(preferences, fmt.Sprintf("%s para %s", tool, task))
+	}
+	if len(preferences) == 0 {
+		return ""
+	}
+	return " Prefiere " + strings.Join(preferences, ", ") + "."
+}
+
+// userContext describe el usuario actual y si es root para el modelo
+func userContext() string {
+	current, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	if IsRoot() {
+		return fmt.Sprintf(" Contexto: el usuario actual es %s y es root, así que no uses sudo.", current.Username)
+	}
+	return fmt.Sprintf(" Contexto: el usuario actual es %s y no es root.", current.Username)
+}
+
+// Resultado de una traducción
+type TranslationResult struct {
+	Raw          string // respuesta de la IA sin sanitizar
+	Command      string
+	Provider     string // proveedor que respondió: el de respaldo si el principal falló
+	Model        string
+	Explanation  string // solo en ExplainCommand
+	TokensUsed   int    // 0 si la respuesta salió de la caché
+	FinishReason string
+	Cached       bool
+
+	// Desglose informado por el proveedor; 0 si no lo informa
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// TranslateToCommand función principal que orquesta la traducción
+func TranslateToCommand(ctx context.Context, userText string) (*TranslationResult, error) {
+	return TranslateWithContext(ctx, userText, nil)
+}
+
+// TranslateWithContext traduce userText incluyendo los turnos previos de la conversación
+func TranslateWithContext(ctx context.Context, userText string, history []ChatTurn) (*TranslationResult, error) {
+	return TranslateStream(ctx, userText, history, nil)
+}
+
+// TranslateStream traduce userText escribiendo la respuesta en out a medida que
+// llega; con out nil la llamada es bloqueante
+func TranslateStream(ctx context.Context, userText string, history []ChatTurn, out io.Writer) (*TranslationResult, error) {
+	system := translationSystemPrompt()
+	request := AIRequest{System: system, Prompt: userText, History: history}
+
+	// Con turnos previos la respuesta depende de la conversación, así que no se cachea
+	primary := GetAIConfig()
+	key := cacheKey(primary, system, userText)
+	if len(history) == 0 {
+		if raw, ok := Cache.Get(key); ok {
+			return &TranslationResult{Raw: raw, Command: SanitizeCommand(raw), Provider: primary.Provider, Model: primary.Model, Cached: true}, nil
+		}
+	}
+
+	response, config, err := callWithFallback(ctx, request, out)
+	if err != nil {
+		// Mensaje de error más amigable
+		return nil, aiCallError(err)
+	}
+	result := &TranslationResult{
+		Raw:          cleanResponse(response.Text),
+		Provider:     config.Provider,
+		Model:        config.Model,
+		TokensUsed:   response.TokensUsed,
+		FinishReason: response.FinishReason,
+
+		PromptTokens:     response.PromptTokens,
+		CompletionTokens: response.CompletionTokens,
+	}
+
+	// Reintentar con más tokens si el comando parece truncado
+	if GetEnvBool("AI_RETRY_TRUNCATED", false) && LooksTruncated(SanitizeCommand(result.Raw)) {
+		retryTokens := config.MaxTokens * truncatedRetryFactor
+		if retried, err := callAI(ctx, AIRequest{System: system, Prompt: userText, MaxTokens: retryTokens, History: history, Config: &config}); err == nil {
+			result.Raw = cleanResponse(retried.Text)
+			result.FinishReason = retried.FinishReason
+			result.TokensUsed += retried.TokensUsed
+			result.PromptTokens += retried.PromptTokens
+			result.CompletionTokens += retried.CompletionTokens
+		}
+	}
+
+	// En modo estricto una respuesta cortada o filtrada es un error, no un comando
+	if GetEnvBool("AI_STRICT_FINISH", false) && !isNormalFinish(result.FinishReason) {
+		return nil, fmt.Errorf("respuesta incompleta de la IA (finish_reason: %s)", result.FinishReason)
+	}
+
+	result.Command = SanitizeCommand(result.Raw)
+	if result.Command == "" {
+		return nil, fmt.Errorf("la IA no pudo generar un comando válido")
+	}
+
+	// Las respuestas del respaldo no se cachean: la caché solo se consulta con el
+	// proveedor principal y no deben servirse después como si fueran suyas
+	answeredByPrimary := config.Provider == primary.Provider && config.Model == primary.Model
+	if len(history) == 0 && answeredByPrimary {
+		Cache.Put(key, result.Raw)
+	}
+	return result, nil
+}
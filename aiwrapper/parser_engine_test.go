@@ -0,0 +1,559 @@
+package aiwrapper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/user"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDumpRequestIncludesHistory(t *testing.T) {
+	t.Setenv("AI_PROVIDER", "openai")
+	t.Setenv("AI_API_KEY", "sk-test-0123456789abcdef")
+	history := []ChatTurn{{User: "listar archivos", Assistant: "ls -la"}}
+
+	dump, err := DumpRequest("ahora ordenados por tamaño", history)
+	if err != nil {
+		t.Fatalf("DumpRequest() error = %v", err)
+	}
+	for _, want := range []string{"listar archivos", "ls -la", "ahora ordenados por tamaño"} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("DumpRequest() = %q, missing %q", dump, want)
+		}
+	}
+	if strings.Contains(dump, "sk-test-0123456789abcdef") {
+		t.Errorf("DumpRequest() leaks the API key: %q", dump)
+	}
+}
+
+func TestSanitizeCommand(t *testing.T) {
+	tests := []struct {
+		name, raw, want string
+	}{
+		{"bloque sin lenguaje", "```\nls -la\n```", "ls -la"},
+		{"bloque bash", "Para listar:\n```bash\nls -la\n```\nEso es todo.", "ls -la"},
+		{"bloque sh con varias líneas", "```sh\n\nfind . -name '*.go'\necho listo\n```", "find . -name '*.go'"},
+		{"bloque con viñeta", "```bash\n1. df -h\n```", "df -h"},
+		{"inline", "Usa `du -sh *` para ver los tamaños", "du -sh *"},
+		{"primera línea", "ps aux", "ps aux"},
+		{"explicación en español", "Para ver el espacio libre:\ndf -h", "df -h"},
+		{"explicación en inglés", "You can list the files with:\nls -la", "ls -la"},
+		{"prompt $", "$ git status", "git status"},
+		{"prompt neri>", "neri> uptime", "uptime"},
+		{"prompt Emiliano>", "Emiliano> whoami", "whoami"},
+		{"prompt PowerShell", "PS C:\\Users> Get-ChildItem", "Get-ChildItem"},
+		{"solo explicación", "Para eso usa el administrador de archivos.\nEste comando no existe.", ""},
+		{"vacía", "  \n ", ""},
+	}
+	for _, tt := range tests {
+		if got := SanitizeCommand(tt.raw); got != tt.want {
+			t.Errorf("%s: SanitizeCommand(%q) = %q, want %q", tt.name, tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestParseCommandInfoLanguage(t *testing.T) {
+	info := ParseCommandInfo("```python\nimport os\nprint(os.listdir())\n```")
+	if info.Language != "python" || info.Command != "import os\nprint(os.listdir())" {
+		t.Errorf("ParseCommandInfo(python) = %+v, want the whole block marked as python", info)
+	}
+	if info := ParseCommandInfo("```bash\nls\n```"); info.Language != "" {
+		t.Errorf("ParseCommandInfo(bash).Language = %q, want empty", info.Language)
+	}
+}
+
+func TestStripANSI(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"ls -la", "ls -la"},
+		{"\x1b[32mls -la\x1b[0m", "ls -la"},
+		{"\x1b[1;31mrm\x1b[m archivo", "rm archivo"},
+		{"\x1b[2K\x1b[1Gdf -h", "df -h"},
+		{"\x1b[?25lps aux\x1b[?25h", "ps aux"},
+		{"\x1b]0;título\x07uptime", "uptime"},
+		{"\x1b]8;;https://example.com\x1b\\enlace\x1b]8;;\x1b\\", "enlace"},
+		{"\x1bMwhoami", "whoami"},
+	}
+	for _, tt := range tests {
+		if got := stripANSI(tt.in); got != tt.want {
+			t.Errorf("stripANSI(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCleanResponse(t *testing.T) {
+	tests := []struct {
+		sanitize, in, want string
+	}{
+		{"", "ls -la", "ls -la"},
+		{"", "ls \xff\xfe-la", "ls \uFFFD-la"},
+		{"", "echo \xc3", "echo \uFFFD"},
+		{"", "echo ñandú", "echo ñandú"},
+		{"", "\x1b[32mls \x80\x1b[0m", "ls \uFFFD"},
+		{"false", "ls \xff-la", "ls \xff-la"},
+	}
+	for _, tt := range tests {
+		t.Setenv("AI_SANITIZE_UTF8", tt.sanitize)
+		t.Setenv("AI_STRIP_ANSI", "")
+		if got := cleanResponse(tt.in); got != tt.want {
+			t.Errorf("cleanResponse(%q) with AI_SANITIZE_UTF8=%q = %q, want %q", tt.in, tt.sanitize, got, tt.want)
+		}
+	}
+}
+
+func TestLooksTruncated(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want bool
+	}{
+		{"ls -la", false},
+		{"echo 'hola mundo'", false},
+		{`echo "dice \"hola\""`, false},
+		{"echo 'sin cerrar", true},
+		{`grep "patrón`, true},
+		{"make &&", true},
+		{"make && ", true},
+		{"test -f x ||", true},
+		{"ps aux |", true},
+		{"echo hola >", true},
+		{"find . -name x \\", true},
+		{"echo 'a && b'", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := LooksTruncated(tt.cmd); got != tt.want {
+			t.Errorf("LooksTruncated(%q) = %v, want %v", tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestExtractPlaceholders(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want []string
+	}{
+		{"ls -la", nil},
+		{"scp <ARCHIVO> <USUARIO>@<HOST>:/tmp", []string{"ARCHIVO", "USUARIO", "HOST"}},
+		{"cp <ORIGEN> <DESTINO> && rm <ORIGEN>", []string{"ORIGEN", "DESTINO"}},
+		{"sort < entrada.txt > salida.txt", nil},
+		{"echo <minusculas> <PUERTO_2>", []string{"PUERTO_2"}},
+	}
+	for _, tt := range tests {
+		if got := ExtractPlaceholders(tt.cmd); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ExtractPlaceholders(%q) = %q, want %q", tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestFillPlaceholders(t *testing.T) {
+	values := map[string]string{"ARCHIVO": "notas.txt", "HOST": "servidor"}
+	tests := []struct {
+		cmd, want string
+	}{
+		{"scp <ARCHIVO> <HOST>:/tmp", "scp notas.txt servidor:/tmp"},
+		{"cat <ARCHIVO> <ARCHIVO>", "cat notas.txt notas.txt"},
+		{"ssh <USUARIO>@<HOST>", "ssh <USUARIO>@servidor"},
+		{"ls -la", "ls -la"},
+	}
+	for _, tt := range tests {
+		if got := FillPlaceholders(tt.cmd, values); got != tt.want {
+			t.Errorf("FillPlaceholders(%q) = %q, want %q", tt.cmd, got, tt.want)
+		}
+	}
+}
+
+// roundTripFunc permite usar una función como http.RoundTripper en los tests
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTranslateToCommandWithTransport(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("AI_CACHE", "false")
+	t.Setenv("AI_PROVIDER", "openai")
+	t.Setenv("AI_BASE_URL", "")
+	t.Setenv("AI_MODEL", "gpt-4o-mini")
+	t.Setenv("AI_API_KEY", "sk-test-0123456789abcdef")
+	t.Setenv("AI_FALLBACK_PROVIDER", "")
+
+	saved := Cache
+	Cache = NewResponseCache()
+	defer func() { Cache = saved }()
+
+	var gotURL, gotAuth, gotBody string
+	SetHTTPTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		gotURL = req.URL.String()
+		gotAuth = req.Header.Get("Authorization")
+		body, _ := io.ReadAll(req.Body)
+		gotBody = string(body)
+		response := `{"choices":[{"message":{"content":"` + "```bash\\nls -la\\n```" + `"},"finish_reason":"stop"}],"usage":{"total_tokens":42}}`
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(response)),
+			Request:    req,
+		}, nil
+	}))
+	defer SetHTTPTransport(nil)
+
+	result, err := TranslateToCommand(context.Background(), "listar archivos")
+	if err != nil {
+		t.Fatalf("TranslateToCommand() error = %v", err)
+	}
+	if result.Command != "ls -la" || result.Provider != "openai" || result.Model != "gpt-4o-mini" || result.TokensUsed != 42 {
+		t.Errorf("TranslateToCommand() = %+v, want ls -la from openai/gpt-4o-mini with 42 tokens", result)
+	}
+	if gotURL != "https://api.openai.com/v1/chat/completions" {
+		t.Errorf("request URL = %q", gotURL)
+	}
+	if gotAuth != "Bearer sk-test-0123456789abcdef" {
+		t.Errorf("Authorization = %q", gotAuth)
+	}
+	if !strings.Contains(gotBody, "listar archivos") || !strings.Contains(gotBody, `"gpt-4o-mini"`) {
+		t.Errorf("request body = %s, missing prompt or model", gotBody)
+	}
+}
+
+func TestCheckRedirect(t *testing.T) {
+	t.Setenv("AI_FOLLOW_REDIRECTS", "true")
+	otherHostHit := false
+	otherHost := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otherHostHit = true
+	}))
+	defer otherHost.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/same":
+			http.Redirect(w, r, "/final", http.StatusTemporaryRedirect)
+		case "/cross":
+			http.Redirect(w, r, otherHost.URL+"/final", http.StatusTemporaryRedirect)
+		case "/get":
+			http.Redirect(w, r, "/final", http.StatusFound)
+		case "/final":
+			if r.Header.Get("Authorization") != "Bearer clave" {
+				http.Error(w, "sin API key", http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer server.Close()
+
+	client := newHTTPClient(AIConfig{Timeout: 5 * time.Second})
+	post := func(path string) (*http.Response, error) {
+		req, err := http.NewRequest(http.MethodPost, server.URL+path, strings.NewReader("{}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer clave")
+		return client.Do(req)
+	}
+
+	resp, err := post("/same")
+	if err != nil {
+		t.Fatalf("same-host redirect error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("same-host redirect status = %d, want 200 with the API key kept", resp.StatusCode)
+	}
+
+	if _, err := post("/cross"); err == nil || !strings.Contains(err.Error(), "otro host") {
+		t.Errorf("cross-host redirect error = %v, want refusal", err)
+	}
+	if otherHostHit {
+		t.Error("cross-host redirect reached the other host")
+	}
+
+	if _, err := post("/get"); err == nil || !strings.Contains(err.Error(), "cambia POST por GET") {
+		t.Errorf("302 redirect error = %v, want method change refusal", err)
+	}
+
+	t.Setenv("AI_FOLLOW_REDIRECTS", "false")
+	if _, err := post("/same"); err == nil || !strings.Contains(err.Error(), "AI_FOLLOW_REDIRECTS") {
+		t.Errorf("redirect with AI_FOLLOW_REDIRECTS=false error = %v, want refusal", err)
+	}
+}
+
+func TestParseTeachResponse(t *testing.T) {
+	tests := []struct {
+		name, raw, command string
+		annotations        []FlagAnnotation
+	}{
+		{
+			"completa",
+			"```bash\ntar -czf respaldo.tgz fotos\n```\n-c = crea un archivo\n`-z` = comprime con gzip\n- -f = nombre del archivo",
+			"tar -czf respaldo.tgz fotos",
+			[]FlagAnnotation{{"-c", "crea un archivo"}, {"-z", "comprime con gzip"}, {"-f", "nombre del archivo"}},
+		},
+		{"sin anotaciones", "ls -la", "ls -la", nil},
+		{"sin comando", "-l = formato largo\n-a = incluye ocultos", "", []FlagAnnotation{{"-l", "formato largo"}, {"-a", "incluye ocultos"}}},
+		{
+			"anotaciones mal formadas",
+			"du -sh .\n-s: resume\n-h=legible\n-s = resume el total\nnota suelta al final",
+			"du -sh .",
+			[]FlagAnnotation{{"-s", "resume el total"}},
+		},
+	}
+	for _, tt := range tests {
+		command, annotations := parseTeachResponse(tt.raw)
+		if command != tt.command || !reflect.DeepEqual(annotations, tt.annotations) {
+			t.Errorf("%s: parseTeachResponse() = %q, %+v, want %q, %+v", tt.name, command, annotations, tt.command, tt.annotations)
+		}
+	}
+}
+
+func TestGetProviderTimeout(t *testing.T) {
+	tests := []struct {
+		provider, providerValue, global string
+		want                            time.Duration
+	}{
+		{"openai", "", "", 30 * time.Second},
+		{"openai", "5", "", 5 * time.Second},
+		{"openai", "", "12", 12 * time.Second},
+		{"openai", "5", "12", 5 * time.Second},
+		{"openai", "0", "12", 0},
+		{"openai", "abc", "12", 12 * time.Second},
+		{"openai", "-3", "", 30 * time.Second},
+		{"openai", "", "xyz", 30 * time.Second},
+		{"openai-compatible", "7", "", 7 * time.Second},
+	}
+	for _, tt := range tests {
+		providerKey := "AI_" + strings.ToUpper(strings.ReplaceAll(tt.provider, "-", "_")) + "_TIMEOUT"
+		t.Setenv(providerKey, tt.providerValue)
+		t.Setenv("AI_TIMEOUT_SECONDS", tt.global)
+		t.Setenv("AI_TIMEOUT", "")
+		if got := getProviderTimeout(tt.provider, 30*time.Second); got != tt.want {
+			t.Errorf("getProviderTimeout(%q) with %s=%q, AI_TIMEOUT_SECONDS=%q = %v, want %v",
+				tt.provider, providerKey, tt.providerValue, tt.global, got, tt.want)
+		}
+	}
+
+	t.Setenv("AI_OPENAI_TIMEOUT", "")
+	t.Setenv("AI_TIMEOUT_SECONDS", "")
+	t.Setenv("AI_TIMEOUT", "9")
+	if got := getProviderTimeout("openai", 30*time.Second); got != 9*time.Second {
+		t.Errorf("getProviderTimeout() with AI_TIMEOUT=9 = %v, want 9s", got)
+	}
+}
+
+func TestFixCommandWithTransport(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("AI_PROVIDER", "openai")
+	t.Setenv("AI_BASE_URL", "")
+	t.Setenv("AI_MODEL", "gpt-4o-mini")
+	t.Setenv("AI_API_KEY", "sk-test-0123456789abcdef")
+	t.Setenv("AI_FALLBACK_PROVIDER", "")
+
+	var gotBody, content string
+	SetHTTPTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(req.Body)
+		gotBody = string(body)
+		response := `{"choices":[{"message":{"content":"` + content + `"},"finish_reason":"stop"}]}`
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(response)),
+			Request:    req,
+		}, nil
+	}))
+	defer SetHTTPTransport(nil)
+
+	content = "```bash\\ngit push --set-upstream origin main\\n```"
+	_, fixed, err := FixCommand(context.Background(), "git push", "fatal: The current branch main has no upstream branch.")
+	if err != nil {
+		t.Fatalf("FixCommand() error = %v", err)
+	}
+	if fixed != "git push --set-upstream origin main" {
+		t.Errorf("FixCommand() = %q, want the corrected command", fixed)
+	}
+	for _, want := range []string{"Comando: git push", "Error: fatal: The current branch main has no upstream branch."} {
+		if !strings.Contains(gotBody, want) {
+			t.Errorf("request body = %s, missing %q", gotBody, want)
+		}
+	}
+
+	content = ""
+	if _, fixed, err := FixCommand(context.Background(), "git push", ""); err == nil {
+		t.Errorf("FixCommand() with an empty answer = %q, want error", fixed)
+	}
+	if strings.Contains(gotBody, "Error:") {
+		t.Errorf("request body = %s, want no Error line without output", gotBody)
+	}
+}
+
+func TestDefaultMaxTokensForModel(t *testing.T) {
+	tests := []struct {
+		model string
+		want  int
+	}{
+		{"codellama:13b", 512},
+		{"deepseek-coder", 512},
+		{"qwen2.5-coder:7b", 512},
+		{"gpt-4o-mini", 256},
+		{"GPT-4", 256},
+		{"gpt-3.5-turbo", 100},
+		{"gemini-1.5-flash", 256},
+		{"claude-3-haiku-20240307", 256},
+		{"llama3", 150},
+		{"mistral", 150},
+		{"modelo-desconocido", defaultMaxTokens},
+		{"", defaultMaxTokens},
+	}
+	for _, tt := range tests {
+		if got := defaultMaxTokensForModel(tt.model); got != tt.want {
+			t.Errorf("defaultMaxTokensForModel(%q) = %d, want %d", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestConfiguredMaxTokens(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   int
+		wantOK bool
+		limit  int
+	}{
+		{"", 0, false, 256},
+		{"1000", 1000, true, 1000},
+		{"0", 0, false, 256},
+		{"-5", 0, false, 256},
+		{"mucho", 0, false, 256},
+	}
+	for _, tt := range tests {
+		t.Setenv("AI_MAX_TOKENS", tt.value)
+		got, ok := configuredMaxTokens()
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("configuredMaxTokens() with AI_MAX_TOKENS=%q = %d, %v, want %d, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+		if limit := getMaxTokens("gpt-4o"); limit != tt.limit {
+			t.Errorf("getMaxTokens(gpt-4o) with AI_MAX_TOKENS=%q = %d, want %d", tt.value, limit, tt.limit)
+		}
+	}
+}
+
+func TestUserContext(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	current, err := user.Current()
+	if err != nil {
+		t.Skipf("user.Current: %v", err)
+	}
+
+	t.Setenv("AI_INCLUDE_USER", "")
+	if system := translationSystemPrompt(); strings.Contains(system, "Contexto:") {
+		t.Errorf("translationSystemPrompt() without AI_INCLUDE_USER = %q, want no user context", system)
+	}
+
+	t.Setenv("AI_INCLUDE_USER", "true")
+	system := translationSystemPrompt()
+	if !strings.Contains(system, userContext()) || !strings.Contains(system, current.Username) {
+		t.Errorf("translationSystemPrompt() with AI_INCLUDE_USER = %q, want the user context for %s", system, current.Username)
+	}
+
+	wantRoot := os.Geteuid() == 0
+	if got := strings.Contains(userContext(), "es root, así que no uses sudo"); got != wantRoot {
+		t.Errorf("userContext() = %q, root = %v, want root = %v", userContext(), got, wantRoot)
+	}
+	if got := strings.Contains(userContext(), "no es root"); got == wantRoot {
+		t.Errorf("userContext() = %q, want the non-root note only when not root", userContext())
+	}
+}
+
+func TestPreferredToolsHint(t *testing.T) {
+	tests := []struct {
+		spec, want string
+	}{
+		{"", ""},
+		{"search=rg", " Prefiere rg para search."},
+		{" search = rg , json=jq ,", " Prefiere rg para search, jq para json."},
+		{"search=rg,invalido,=fd,json=", " Prefiere rg para search."},
+		{"sin-igual", ""},
+	}
+	for _, tt := range tests {
+		if got := preferredToolsHint(tt.spec); got != tt.want {
+			t.Errorf("preferredToolsHint(%q) = %q, want %q", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestIsNormalFinish(t *testing.T) {
+	tests := []struct {
+		reason string
+		want   bool
+	}{
+		{"", true},
+		{"stop", true},
+		{"STOP", true},
+		{"end_turn", true},
+		{"stop_sequence", true},
+		{"length", false},
+		{"max_tokens", false},
+		{"MAX_TOKENS", false},
+		{"content_filter", false},
+	}
+	for _, tt := range tests {
+		if got := isNormalFinish(tt.reason); got != tt.want {
+			t.Errorf("isNormalFinish(%q) = %v, want %v", tt.reason, got, tt.want)
+		}
+	}
+}
+
+func TestTranslateStrictFinish(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("AI_CACHE", "false")
+	t.Setenv("AI_PROVIDER", "openai")
+	t.Setenv("AI_BASE_URL", "")
+	t.Setenv("AI_MODEL", "gpt-4o-mini")
+	t.Setenv("AI_API_KEY", "sk-test-0123456789abcdef")
+	t.Setenv("AI_FALLBACK_PROVIDER", "")
+	t.Setenv("AI_RETRY_TRUNCATED", "false")
+
+	var finishReason string
+	SetHTTPTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		response := `{"choices":[{"message":{"content":"find . -name"},"finish_reason":"` + finishReason + `"}]}`
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(response)),
+			Request:    req,
+		}, nil
+	}))
+	defer SetHTTPTransport(nil)
+
+	tests := []struct {
+		strict, reason string
+		wantErr        bool
+	}{
+		{"true", "length", true},
+		{"true", "max_tokens", true},
+		{"true", "stop", false},
+		{"false", "length", false},
+	}
+	for _, tt := range tests {
+		saved := Cache
+		Cache = NewResponseCache()
+		t.Setenv("AI_STRICT_FINISH", tt.strict)
+		finishReason = tt.reason
+
+		result, err := TranslateToCommand(context.Background(), "buscar por nombre")
+		Cache = saved
+		switch {
+		case tt.wantErr && (err == nil || !strings.Contains(err.Error(), "finish_reason: "+tt.reason)):
+			t.Errorf("TranslateToCommand() with AI_STRICT_FINISH=%s, finish_reason %s error = %v, want incomplete response error", tt.strict, tt.reason, err)
+		case !tt.wantErr && err != nil:
+			t.Errorf("TranslateToCommand() with AI_STRICT_FINISH=%s, finish_reason %s error = %v", tt.strict, tt.reason, err)
+		case !tt.wantErr && result.FinishReason != tt.reason:
+			t.Errorf("TranslateToCommand() FinishReason = %q, want %q", result.FinishReason, tt.reason)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package aiwrapper
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// shellFields divide un comando en palabras y operadores, respetando comillas.
+// Las comillas se eliminan de las palabras resultantes.
+func shellFields(cmd string) []string {
+	var fields []string
+	var current strings.Builder
+	inWord := false
+
+	flush := func() {
+		if inWord {
+			fields = append(fields, current.String())
+			current.Reset()
+			inWord = false
+		}
+	}
+
+	for i := 0; i < len(cmd); {
+		c := cmd[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			flush()
+			i++
+		case c == '\'' || c == '"':
+			end := closingQuote(cmd, i)
+			if end > i+1 && cmd[end-1] == c {
+				current.WriteString(cmd[i+1 : end-1])
+			} else {
+				// Comilla sin cerrar: tomar el resto
+				current.WriteString(cmd[i+1:])
+			}
+			inWord = true
+			i = end
+		case c == '\\' && i+1 < len(cmd):
+			current.WriteByte(cmd[i+1])
+			inWord = true
+			i += 2
+		default:
+			// "2>" solo es operador al inicio de una palabra
+			if op := matchOperator(cmd[i:]); op != "" && !(op == "2>" && inWord) {
+				flush()
+				fields = append(fields, op)
+				i += len(op)
+				continue
+			}
+			current.WriteByte(c)
+			inWord = true
+			i++
+		}
+	}
+	flush()
+	return fields
+}
+
+// isShellOperator verifica si el campo es un operador de shell
+func isShellOperator(field string) bool {
+	for _, op := range shellOperators {
+		if field == op {
+			return true
+		}
+	}
+	return false
+}
+
+// isRedirect verifica si el operador es una redirección (el siguiente campo es una ruta)
+func isRedirect(op string) bool {
+	return op == ">" || op == ">>" || op == "<" || op == "2>"
+}
+
+// looksLikePath verifica si un argumento parece una ruta
+func looksLikePath(arg, cwd string) bool {
+	if arg == "" || strings.HasPrefix(arg, "-") {
+		return false
+	}
+	if arg == "." || arg == ".." || arg == "~" || strings.HasPrefix(arg, "~/") || strings.Contains(arg, "/") {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(cwd, arg))
+	return err == nil
+}
+
+// resolvePath convierte una ruta relativa o con ~ en absoluta respecto a cwd
+func resolvePath(arg, cwd string) string {
+	if arg == "~" || strings.HasPrefix(arg, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(arg, "~"))
+		}
+		return arg
+	}
+	if filepath.IsAbs(arg) {
+		return filepath.Clean(arg)
+	}
+	return filepath.Join(cwd, arg)
+}
+
+// ResolveCommandPaths relaciona cada argumento tipo ruta con su forma absoluta
+func ResolveCommandPaths(cmd, cwd string) map[string]string {
+	paths := make(map[string]string)
+	expectBinary := true
+	afterRedirect := false
+
+	for _, field := range shellFields(cmd) {
+		if isShellOperator(field) {
+			afterRedirect = isRedirect(field)
+			expectBinary = !afterRedirect
+			continue
+		}
+		if expectBinary {
+			expectBinary = false
+			continue
+		}
+		if afterRedirect || looksLikePath(field, cwd) {
+			paths[field] = resolvePath(field, cwd)
+		}
+		afterRedirect = false
+	}
+	return paths
+}
+
+// shellOperators ordenados de mayor a menor longitud para reconocer primero los compuestos
+var shellOperators = []string{"&&", "||", ">>", "2>", "|", ";", ">", "<", "&"}
+
+// matchOperator devuelve el operador de shell al inicio de s, o ""
+func matchOperator(s string) string {
+	for _, op := range shellOperators {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// closingQuote devuelve la posición tras la comilla que cierra la iniciada en start
+func closingQuote(s string, start int) int {
+	quote := s[start]
+	for i := start + 1; i < len(s); i++ {
+		if s[i] == '\\' && quote == '"' {
+			i++
+			continue
+		}
+		if s[i] == quote {
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// Tipos de tramo de CommandSegments
+const (
+	SegmentSpace    = "space"    // espacios y tabuladores
+	SegmentOperator = "operator" // separa comandos: |, ;, &&, ||, &
+	SegmentRedirect = "redirect" // >, >>, <, 2>; lo que sigue es una ruta
+	SegmentQuoted   = "quoted"   // cadena entre comillas, con las comillas
+	SegmentWord     = "word"     // binario, flag o argumento
+)
+
+// CommandSegment es un tramo del comando con su texto original
+type CommandSegment struct {
+	Kind string
+	Text string
+}
+
+// CommandSegments divide el comando en tramos conservando el texto original,
+// p. ej. para resaltarlo: al concatenar los Text se obtiene cmd
+func CommandSegments(cmd string) []CommandSegment {
+	var segments []CommandSegment
+	for i := 0; i < len(cmd); {
+		c := cmd[i]
+		switch {
+		case c == ' ' || c == '\t':
+			end := i
+			for end < len(cmd) && (cmd[end] == ' ' || cmd[end] == '\t') {
+				end++
+			}
+			segments = append(segments, CommandSegment{SegmentSpace, cmd[i:end]})
+			i = end
+		case matchOperator(cmd[i:]) != "":
+			op := matchOperator(cmd[i:])
+			kind := SegmentOperator
+			if isRedirect(op) {
+				kind = SegmentRedirect
+			}
+			segments = append(segments, CommandSegment{kind, op})
+			i += len(op)
+		case c == '\'' || c == '"':
+			end := closingQuote(cmd, i)
+			segments = append(segments, CommandSegment{SegmentQuoted, cmd[i:end]})
+			i = end
+		default:
+			end := i
+			for end < len(cmd) && cmd[end] != ' ' && cmd[end] != '\t' && matchOperator(cmd[end:]) == "" {
+				end++
+			}
+			segments = append(segments, CommandSegment{SegmentWord, cmd[i:end]})
+			i = end
+		}
+	}
+	return segments
+}
@@ -0,0 +1,70 @@
+package aiwrapper
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveCommandPaths(t *testing.T) {
+	cwd := t.TempDir()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.WriteFile(filepath.Join(cwd, "notas.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		cmd  string
+		want map[string]string
+	}{
+		{"ls -la", map[string]string{}},
+		{"cat notas.txt", map[string]string{"notas.txt": filepath.Join(cwd, "notas.txt")}},
+		{"cat inexistente", map[string]string{}},
+		{"cp ./a ../b", map[string]string{"./a": filepath.Join(cwd, "a"), "../b": filepath.Join(filepath.Dir(cwd), "b")}},
+		{"ls /etc//ssh/", map[string]string{"/etc//ssh/": "/etc/ssh"}},
+		{"du -sh ~/Descargas", map[string]string{"~/Descargas": filepath.Join(home, "Descargas")}},
+		{"cd ~", map[string]string{"~": home}},
+		{"echo hola > salida.log", map[string]string{"salida.log": filepath.Join(cwd, "salida.log")}},
+		{"./build.sh | tee out/log", map[string]string{"out/log": filepath.Join(cwd, "out/log")}},
+	}
+	for _, tt := range tests {
+		if got := ResolveCommandPaths(tt.cmd, cwd); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ResolveCommandPaths(%q) = %v, want %v", tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestCommandSegments(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want []CommandSegment
+	}{
+		{"", nil},
+		{"ls  -la", []CommandSegment{{SegmentWord, "ls"}, {SegmentSpace, "  "}, {SegmentWord, "-la"}}},
+		{`grep "a b"|wc -l`, []CommandSegment{
+			{SegmentWord, "grep"}, {SegmentSpace, " "}, {SegmentQuoted, `"a b"`}, {SegmentOperator, "|"},
+			{SegmentWord, "wc"}, {SegmentSpace, " "}, {SegmentWord, "-l"},
+		}},
+		{"make 2> err.log && echo ok", []CommandSegment{
+			{SegmentWord, "make"}, {SegmentSpace, " "}, {SegmentRedirect, "2>"}, {SegmentSpace, " "}, {SegmentWord, "err.log"},
+			{SegmentSpace, " "}, {SegmentOperator, "&&"}, {SegmentSpace, " "}, {SegmentWord, "echo"}, {SegmentSpace, " "}, {SegmentWord, "ok"},
+		}},
+		{"echo 'sin cerrar", []CommandSegment{{SegmentWord, "echo"}, {SegmentSpace, " "}, {SegmentQuoted, "'sin cerrar"}}},
+	}
+	for _, tt := range tests {
+		got := CommandSegments(tt.cmd)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("CommandSegments(%q) = %v, want %v", tt.cmd, got, tt.want)
+		}
+		var text strings.Builder
+		for _, segment := range got {
+			text.WriteString(segment.Text)
+		}
+		if text.String() != tt.cmd {
+			t.Errorf("CommandSegments(%q) text = %q, want the original command", tt.cmd, text.String())
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package aiwrapper
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// projectFileName es el archivo de configuración por proyecto (como .nvmrc)
+const projectFileName = ".neri"
+
+// findProjectFile busca .neri desde dir hacia los directorios padre
+func findProjectFile(dir string) (string, bool) {
+	for {
+		path := filepath.Join(dir, projectFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// projectKeys son las únicas opciones que acepta .neri. Un repositorio clonado
+// no debe poder cambiar la URL base ni otras opciones: con base_url podría
+// hacer que la API key del usuario se envíe a un servidor ajeno.
+var projectKeys = map[string]bool{"AI_PROVIDER": true, "AI_MODEL": true}
+
+// parseProjectFile lee líneas "clave=valor"; las claves se normalizan a AI_CLAVE
+// y se ignoran las que no están en projectKeys
+func parseProjectFile(content string) map[string]string {
+	settings := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.ToUpper(strings.TrimSpace(key))
+		if !strings.HasPrefix(key, "AI_") {
+			key = "AI_" + key
+		}
+		if !projectKeys[key] {
+			warnOnce(projectFileName+key, "⚠️  %s: se ignora %s, solo se aceptan provider y model\n", projectFileName, key)
+			continue
+		}
+		settings[key] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	return settings
+}
+
+// loadProjectSettings carga el .neri más cercano al directorio actual
+func loadProjectSettings() map[string]string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	path, ok := findProjectFile(cwd)
+	if !ok {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return parseProjectFile(string(data))
+}
+
+// getSetting resuelve una opción: variable de entorno, luego .neri, luego el valor por defecto
+func getSetting(project map[string]string, key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	if value := project[key]; value != "" {
+		return value
+	}
+	return defaultValue
+}
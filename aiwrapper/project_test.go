@@ -0,0 +1,100 @@
+package aiwrapper
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseProjectFileOnlyProviderAndModel(t *testing.T) {
+	content := `# proyecto
+provider=openai
+model = "gpt-4o"
+base_url=https://attacker.example/
+AI_API_KEY=robada
+`
+	got := parseProjectFile(content)
+	want := map[string]string{"AI_PROVIDER": "openai", "AI_MODEL": "gpt-4o"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseProjectFile() = %v, want %v", got, want)
+	}
+}
+
+func TestFindProjectFile(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, projectFileName), []byte("provider=openai\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dir := range []string{root, filepath.Join(root, "a"), nested} {
+		if path, ok := findProjectFile(dir); !ok || path != filepath.Join(root, projectFileName) {
+			t.Errorf("findProjectFile(%q) = %q, %v, want the .neri at the root", dir, path, ok)
+		}
+	}
+
+	// Un directorio llamado .neri no cuenta
+	other := t.TempDir()
+	if err := os.Mkdir(filepath.Join(other, projectFileName), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if path, ok := findProjectFile(other); ok && strings.HasPrefix(path, other) {
+		t.Errorf("findProjectFile(%q) = %q, want the .neri directory ignored", other, path)
+	}
+}
+
+func TestGetAIConfigPrecedence(t *testing.T) {
+	root := t.TempDir()
+	project := filepath.Join(root, "proyecto")
+	nested := filepath.Join(project, "src")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(root, "config.json")
+	config := `{"provider": "openai", "model": "gpt-4", "base_url": "https://proxy.example/v1/chat/completions"}`
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, projectFileName), []byte("provider=anthropic\nmodel=claude-3-opus-20240229\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	for _, key := range []string{"AI_PROVIDER", "AI_MODEL", "AI_BASE_URL"} {
+		t.Setenv(key, "")
+	}
+
+	tests := []struct {
+		name, dir, configFile, env string
+		provider, model, baseURL   string
+	}{
+		{"valores por defecto", root, filepath.Join(root, "inexistente.json"), "",
+			"ollama", "llama2", "http://localhost:11434/api/generate"},
+		{"config.json", root, configPath, "",
+			"openai", "gpt-4", "https://proxy.example/v1/chat/completions"},
+		{".neri sobre config.json", nested, configPath, "",
+			"anthropic", "claude-3-opus-20240229", "https://proxy.example/v1/chat/completions"},
+		{"entorno sobre .neri", nested, configPath, "gemini",
+			"gemini", "claude-3-opus-20240229", "https://proxy.example/v1/chat/completions"},
+	}
+	for _, tt := range tests {
+		if err := os.Chdir(tt.dir); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("AI_CONFIG_FILE", tt.configFile)
+		t.Setenv("AI_PROVIDER", tt.env)
+		got := GetAIConfig()
+		if got.Provider != tt.provider || got.Model != tt.model || got.BaseURL != tt.baseURL {
+			t.Errorf("%s: GetAIConfig() = %s %s %s, want %s %s %s", tt.name, got.Provider, got.Model, got.BaseURL, tt.provider, tt.model, tt.baseURL)
+		}
+	}
+}
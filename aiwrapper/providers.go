@@ -0,0 +1,161 @@
+package aiwrapper
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Estilos de autenticación de los proveedores
+const (
+	AuthNone       = "none"           // sin API key (servidores locales)
+	authBearer     = "bearer"         // header Authorization: Bearer <key>
+	authXAPIKey    = "x-api-key"      // header x-api-key: <key>
+	authGoogAPIKey = "x-goog-api-key" // header x-goog-api-key: <key>, la key no va en la URL
+	authAPIKey     = "api-key"        // header api-key: <key> (Azure)
+	AuthOptional   = "optional"       // Authorization: Bearer <key> solo si hay key (servidores compatibles con OpenAI)
+)
+
+// Estilos de payload (formato de petición y respuesta)
+const (
+	payloadOpenAI    = "openai"
+	payloadGemini    = "gemini"
+	payloadOllama    = "ollama"
+	payloadAnthropic = "anthropic"
+	payloadLocal     = "local" // llama-cli en el mismo equipo, sin HTTP
+)
+
+// Valores por defecto de un proveedor conocido
+type ProviderPreset struct {
+	BaseURL      string
+	DefaultModel string
+	AuthStyle    string
+	PayloadStyle string
+
+	// Versión de la API: se envía en VersionHeader o en el parámetro VersionQuery.
+	// AI_API_VERSION reemplaza DefaultVersion.
+	VersionHeader  string
+	VersionQuery   string
+	DefaultVersion string
+
+	// DeploymentPath se agrega a la URL base con el deployment (AI_AZURE_DEPLOYMENT)
+	// en lugar de enviar el modelo solo en el payload
+	DeploymentPath string
+}
+
+// providerPresets registra los proveedores soportados. Agregar un proveedor
+// con un formato existente solo requiere una entrada aquí.
+var providerPresets = map[string]ProviderPreset{
+	"openai": {
+		BaseURL:       "https://api.openai.com/v1/chat/completions",
+		DefaultModel:  "gpt-3.5-turbo",
+		AuthStyle:     authBearer,
+		PayloadStyle:  payloadOpenAI,
+		VersionHeader: "OpenAI-Beta",
+	},
+	"openai-compatible": {
+		// LM Studio, vLLM, llama.cpp server y similares; ajustar AI_BASE_URL al puerto del servidor
+		BaseURL:      "http://localhost:8080/v1/chat/completions",
+		DefaultModel: "local-model",
+		AuthStyle:    AuthOptional,
+		PayloadStyle: payloadOpenAI,
+	},
+	"gemini": {
+		BaseURL:      "https://generativelanguage.googleapis.com/v1beta/models",
+		DefaultModel: "gemini-pro",
+		AuthStyle:    authGoogAPIKey,
+		PayloadStyle: payloadGemini,
+	},
+	"ollama": {
+		BaseURL:      "http://localhost:11434/api/generate",
+		DefaultModel: "llama2",
+		AuthStyle:    AuthNone,
+		PayloadStyle: payloadOllama,
+	},
+	"anthropic": {
+		BaseURL:        "https://api.anthropic.com/v1/messages",
+		DefaultModel:   "claude-3-haiku-20240307",
+		AuthStyle:      authXAPIKey,
+		PayloadStyle:   payloadAnthropic,
+		VersionHeader:  "anthropic-version",
+		DefaultVersion: "2023-06-01",
+	},
+	"azure": {
+		AuthStyle:      authAPIKey,
+		PayloadStyle:   payloadOpenAI,
+		VersionQuery:   "api-version",
+		DefaultVersion: "2024-02-01",
+		DeploymentPath: "/openai/deployments/%s/chat/completions",
+	},
+	"local": {
+		AuthStyle:    AuthNone,
+		PayloadStyle: payloadLocal,
+	},
+}
+
+// ProviderNames devuelve los proveedores registrados en orden alfabético
+func ProviderNames() []string {
+	names := make([]string, 0, len(providerPresets))
+	for name := range providerPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateConfig verifica que la configuración alcance para armar la URL. En
+// Azure la URL se construye con el recurso y el deployment, que no tienen valor
+// por defecto, así que faltando alguno se nombra la variable a definir.
+func validateConfig(config AIConfig) error {
+	preset := providerPresets[config.Provider]
+	if preset.DeploymentPath == "" {
+		return nil
+	}
+	if config.Model == "" {
+		return fmt.Errorf("falta AI_AZURE_DEPLOYMENT (AI_FALLBACK_MODEL en el respaldo): el nombre del deployment de %s", config.Provider)
+	}
+	if strings.HasPrefix(config.BaseURL, "/") {
+		return fmt.Errorf("falta AI_BASE_URL (AI_FALLBACK_BASE_URL en el respaldo): la URL del recurso, p. ej. https://mi-recurso.openai.azure.com")
+	}
+	return nil
+}
+
+// knownModels lista los modelos conocidos de cada proveedor. Azure, local y
+// openai-compatible no tienen lista: el modelo es un deployment, una ruta o lo
+// define el servidor.
+var knownModels = map[string][]string{
+	"openai":    {"gpt-3.5-turbo", "gpt-4", "gpt-4-turbo", "gpt-4o", "gpt-4o-mini"},
+	"gemini":    {"gemini-pro", "gemini-1.5-pro", "gemini-1.5-flash"},
+	"anthropic": {"claude-3-haiku-20240307", "claude-3-sonnet-20240229", "claude-3-opus-20240229", "claude-3-5-sonnet-20240620"},
+	"ollama":    {"llama2", "llama3", "codellama", "mistral", "deepseek-coder", "qwen2.5-coder"},
+}
+
+// KnownModels devuelve los modelos conocidos del proveedor (nil si no tiene lista)
+func KnownModels(provider string) []string {
+	return knownModels[provider]
+}
+
+// DefaultModel devuelve el modelo por defecto del proveedor, o "" si no tiene
+func DefaultModel(provider string) string {
+	return providerPresets[provider].DefaultModel
+}
+
+// CheckModel devuelve una advertencia si el modelo no está entre los conocidos
+// del proveedor, o "" si lo está o el proveedor no tiene lista. En Ollama se
+// ignora la etiqueta (llama2:13b cuenta como llama2).
+func CheckModel(provider, model string) string {
+	models := knownModels[provider]
+	if len(models) == 0 {
+		return ""
+	}
+	name := model
+	if provider == "ollama" {
+		name, _, _ = strings.Cut(model, ":")
+	}
+	for _, known := range models {
+		if name == known {
+			return ""
+		}
+	}
+	return fmt.Sprintf("modelo %q desconocido para %s (conocidos: %s)", model, provider, strings.Join(models, ", "))
+}
@@ -0,0 +1,182 @@
+package aiwrapper
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestAzureConfigValidation(t *testing.T) {
+	tests := []struct {
+		baseURL, deployment string
+		wantErr             string
+	}{
+		{"", "gpt-4o-mini", "AI_BASE_URL"},
+		{"https://mi-recurso.openai.azure.com", "", "AI_AZURE_DEPLOYMENT"},
+		{"https://mi-recurso.openai.azure.com", "gpt-4o-mini", ""},
+	}
+	for _, tt := range tests {
+		t.Setenv("HOME", t.TempDir())
+		t.Setenv("AI_PROVIDER", "azure")
+		t.Setenv("AI_BASE_URL", tt.baseURL)
+		t.Setenv("AI_AZURE_DEPLOYMENT", tt.deployment)
+		t.Setenv("AI_MODEL", "")
+		t.Setenv("AI_API_KEY", "clave-azure")
+
+		_, _, err := buildAPIRequest(GetAIConfig(), AIRequest{Prompt: "listar archivos"})
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("buildAPIRequest(%q, %q) error = %v", tt.baseURL, tt.deployment, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("buildAPIRequest(%q, %q) error = %v, want mention of %s", tt.baseURL, tt.deployment, err, tt.wantErr)
+		}
+	}
+}
+
+func TestOpenAIAttributionHeaders(t *testing.T) {
+	for _, provider := range []string{"openai", "openai-compatible", "anthropic", "gemini", "ollama"} {
+		t.Setenv("HOME", t.TempDir())
+		t.Setenv("AI_PROVIDER", provider)
+		t.Setenv("AI_BASE_URL", "")
+		t.Setenv("AI_MODEL", "")
+		t.Setenv("AI_API_KEY", "clave-de-prueba")
+		t.Setenv("AI_OPENAI_ORG", "org-123")
+		t.Setenv("AI_OPENAI_PROJECT", "proj-456")
+
+		req, _, err := buildAPIRequest(GetAIConfig(), AIRequest{Prompt: "listar archivos"})
+		if err != nil {
+			t.Fatalf("buildAPIRequest(%s) error = %v", provider, err)
+		}
+		wantOrg, wantProject := "", ""
+		if provider == "openai" {
+			wantOrg, wantProject = "org-123", "proj-456"
+		}
+		if got := req.Header.Get("OpenAI-Organization"); got != wantOrg {
+			t.Errorf("%s: OpenAI-Organization = %q, want %q", provider, got, wantOrg)
+		}
+		if got := req.Header.Get("OpenAI-Project"); got != wantProject {
+			t.Errorf("%s: OpenAI-Project = %q, want %q", provider, got, wantProject)
+		}
+	}
+}
+
+func TestOpenAIAttributionBody(t *testing.T) {
+	for _, provider := range []string{"openai", "anthropic", "gemini", "ollama"} {
+		t.Setenv("HOME", t.TempDir())
+		t.Setenv("AI_PROVIDER", provider)
+		t.Setenv("AI_BASE_URL", "")
+		t.Setenv("AI_MODEL", "")
+		t.Setenv("AI_API_KEY", "clave-de-prueba")
+		t.Setenv("AI_USER_ID", "emiliano")
+		t.Setenv("AI_REQUEST_METADATA", `{"equipo":"infra"}`)
+
+		_, body, err := buildAPIRequest(GetAIConfig(), AIRequest{Prompt: "listar archivos"})
+		if err != nil {
+			t.Fatalf("buildAPIRequest(%s) error = %v", provider, err)
+		}
+		var payload struct {
+			User     *string           `json:"user"`
+			Metadata map[string]string `json:"metadata"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Fatalf("%s: invalid body %s: %v", provider, body, err)
+		}
+		if provider == "openai" {
+			if payload.User == nil || *payload.User != "emiliano" || payload.Metadata["equipo"] != "infra" {
+				t.Errorf("openai body = %s, want user and metadata", body)
+			}
+		} else if payload.User != nil || payload.Metadata != nil {
+			t.Errorf("%s body = %s, want no user or metadata", provider, body)
+		}
+	}
+
+	t.Setenv("AI_PROVIDER", "openai")
+	t.Setenv("AI_REQUEST_METADATA", `{"equipo":1}`)
+	if _, _, err := buildAPIRequest(GetAIConfig(), AIRequest{Prompt: "listar archivos"}); err == nil || !strings.Contains(err.Error(), "AI_REQUEST_METADATA") {
+		t.Errorf("buildAPIRequest() with non-string metadata error = %v, want mention of AI_REQUEST_METADATA", err)
+	}
+}
+
+func TestProviderPresetsResolve(t *testing.T) {
+	names := ProviderNames()
+	if len(names) != len(providerPresets) || !sort.StringsAreSorted(names) {
+		t.Errorf("ProviderNames() = %v, want every preset in alphabetical order", names)
+	}
+	for _, provider := range names {
+		t.Setenv("HOME", t.TempDir())
+		t.Setenv("AI_PROVIDER", provider)
+		t.Setenv("AI_BASE_URL", "")
+		t.Setenv("AI_MODEL", "")
+		t.Setenv("AI_AZURE_DEPLOYMENT", "")
+		t.Setenv("AI_MODEL_PATH", "")
+		switch provider {
+		case "azure":
+			t.Setenv("AI_BASE_URL", "https://mi-recurso.openai.azure.com")
+			t.Setenv("AI_AZURE_DEPLOYMENT", "gpt-4o-mini")
+		case "local":
+			t.Setenv("AI_MODEL_PATH", "/modelos/qwen2.5-coder.gguf")
+		}
+
+		config := GetAIConfig()
+		if config.Provider != provider || config.PayloadStyle == "" || config.AuthStyle == "" {
+			t.Errorf("GetAIConfig() for %s = %+v, want the preset styles", provider, config)
+		}
+		if config.Model == "" {
+			t.Errorf("GetAIConfig() for %s: empty model", provider)
+		}
+		if want := DefaultModel(provider); want != "" && config.Model != want {
+			t.Errorf("GetAIConfig() for %s: model = %q, want DefaultModel %q", provider, config.Model, want)
+		}
+		// local ejecuta llama-cli sin HTTP, así que no tiene URL
+		if config.PayloadStyle != payloadLocal && !strings.HasPrefix(config.BaseURL, "http") {
+			t.Errorf("GetAIConfig() for %s: base URL = %q, want an http(s) URL", provider, config.BaseURL)
+		}
+	}
+
+	if got := DefaultModel("desconocido"); got != "" {
+		t.Errorf("DefaultModel(desconocido) = %q, want empty", got)
+	}
+}
+
+func TestApplyAPIVersion(t *testing.T) {
+	tests := []struct {
+		provider, version, azureVersion string
+		header, headerValue, query      string
+	}{
+		{"anthropic", "", "", "anthropic-version", "2023-06-01", ""},
+		{"anthropic", "2024-01-01", "", "anthropic-version", "2024-01-01", ""},
+		{"openai", "", "", "OpenAI-Beta", "", ""},
+		{"openai", "assistants=v2", "", "OpenAI-Beta", "assistants=v2", ""},
+		{"azure", "", "", "", "", "2024-02-01"},
+		{"azure", "2024-06-01", "", "", "", "2024-06-01"},
+		{"azure", "2024-06-01", "2024-10-21", "", "", "2024-10-21"},
+		{"gemini", "v1", "", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Setenv("HOME", t.TempDir())
+		t.Setenv("AI_PROVIDER", tt.provider)
+		t.Setenv("AI_BASE_URL", "")
+		t.Setenv("AI_MODEL", "")
+		t.Setenv("AI_API_KEY", "clave-de-prueba")
+		t.Setenv("AI_API_VERSION", tt.version)
+		t.Setenv("AI_AZURE_API_VERSION", tt.azureVersion)
+		t.Setenv("AI_AZURE_DEPLOYMENT", "gpt-4o-mini")
+		if tt.provider == "azure" {
+			t.Setenv("AI_BASE_URL", "https://mi-recurso.openai.azure.com")
+		}
+
+		req, _, err := buildAPIRequest(GetAIConfig(), AIRequest{Prompt: "listar archivos"})
+		if err != nil {
+			t.Fatalf("buildAPIRequest(%s) error = %v", tt.provider, err)
+		}
+		if tt.header != "" {
+			if got := req.Header.Get(tt.header); got != tt.headerValue {
+				t.Errorf("%s with AI_API_VERSION=%q: %s = %q, want %q", tt.provider, tt.version, tt.header, got, tt.headerValue)
+			}
+		}
+		if got := req.URL.Query().Get("api-version"); got != tt.query {
+			t.Errorf("%s with AI_API_VERSION=%q: api-version query = %q, want %q", tt.provider, tt.version, got, tt.query)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package aiwrapper
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// minRedactLength evita que una key de prueba muy corta enmascare texto normal
+const minRedactLength = 4
+
+// configuredAPIKeys devuelve las API keys configuradas (principal, respaldo y config.json)
+func configuredAPIKeys() []string {
+	return []string{os.Getenv("AI_API_KEY"), os.Getenv("AI_FALLBACK_API_KEY"), loadConfigFile().APIKey}
+}
+
+// Redact oculta en s cualquier API key configurada
+func Redact(s string) string {
+	for _, key := range configuredAPIKeys() {
+		if len(key) >= minRedactLength {
+			s = strings.ReplaceAll(s, key, "****")
+		}
+	}
+	return s
+}
+
+// redactError devuelve el error con las API keys ocultas; si no había ninguna
+// devuelve el mismo error para que errors.Is siga funcionando
+func redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	redacted := Redact(err.Error())
+	if redacted == err.Error() {
+		return err
+	}
+	return errors.New(redacted)
+}
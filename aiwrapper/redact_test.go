@@ -0,0 +1,49 @@
+package aiwrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("AI_API_KEY", "sk-principal-123")
+	t.Setenv("AI_FALLBACK_API_KEY", "abc")
+
+	tests := []struct {
+		in, want string
+	}{
+		{"Authorization: Bearer sk-principal-123", "Authorization: Bearer ****"},
+		{"https://x/?key=sk-principal-123&k2=sk-principal-123", "https://x/?key=****&k2=****"},
+		{"sin claves", "sin claves"},
+		// La key de respaldo es más corta que minRedactLength y no se enmascara
+		{"abcdef", "abcdef"},
+	}
+	for _, tt := range tests {
+		if got := Redact(tt.in); got != tt.want {
+			t.Errorf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRedactError(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("AI_API_KEY", "sk-principal-123")
+	t.Setenv("AI_FALLBACK_API_KEY", "")
+
+	if redactError(nil) != nil {
+		t.Error("redactError(nil) != nil")
+	}
+
+	err := fmt.Errorf("error en request HTTP: %w", context.DeadlineExceeded)
+	if got := redactError(err); got != err || !errors.Is(got, context.DeadlineExceeded) {
+		t.Errorf("redactError(%v) = %v, want the same error", err, got)
+	}
+
+	err = fmt.Errorf("GET https://api/?key=sk-principal-123: 401")
+	if got := redactError(err); got.Error() != "GET https://api/?key=****: 401" {
+		t.Errorf("redactError(%v) = %v, want the key masked", err, got)
+	}
+}
@@ -0,0 +1,111 @@
+package aiwrapper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// defaultMaxRetries es la cantidad de reintentos ante fallos transitorios
+const defaultMaxRetries = 2
+
+// defaultRetryBaseDelay es la espera antes del primer reintento; se duplica en cada uno
+const defaultRetryBaseDelay = 500 * time.Millisecond
+
+// getMaxRetries obtiene los reintentos de AI_MAX_RETRIES (0 los desactiva)
+func getMaxRetries() int {
+	if value, err := strconv.Atoi(os.Getenv("AI_MAX_RETRIES")); err == nil && value >= 0 {
+		return value
+	}
+	return defaultMaxRetries
+}
+
+// getRetryBaseDelay obtiene la espera base de AI_RETRY_BASE_DELAY_MS
+func getRetryBaseDelay() time.Duration {
+	if value, err := strconv.Atoi(os.Getenv("AI_RETRY_BASE_DELAY_MS")); err == nil && value > 0 {
+		return time.Duration(value) * time.Millisecond
+	}
+	return defaultRetryBaseDelay
+}
+
+// isRetryableStatus indica si el código HTTP es un fallo transitorio del servidor
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// maxRetryDelay limita la espera entre reintentos, por grande que sea AI_MAX_RETRIES
+const maxRetryDelay = 30 * time.Second
+
+// maxRetryShift limita el exponente para que base·2^intento no desborde
+const maxRetryShift = 16
+
+// retryDelay calcula la espera del reintento: base·2^intento más un jitter
+// aleatorio de hasta base, sin superar maxRetryDelay
+func retryDelay(attempt int, base time.Duration) time.Duration {
+	if attempt > maxRetryShift {
+		attempt = maxRetryShift
+	}
+	delay := base<<attempt + time.Duration(rand.Int63n(int64(base)))
+	if delay <= 0 || delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return delay
+}
+
+// sendWithRetry envía la petición a la IA reintentando ante errores de red y
+// 429/500/502/503/504. Los demás códigos se devuelven tal cual al llamador.
+func sendWithRetry(ctx context.Context, config AIConfig, request AIRequest) (*http.Response, error) {
+	client := newHTTPClient(config)
+	maxRetries := getMaxRetries()
+	baseDelay := getRetryBaseDelay()
+
+	for attempt := 0; ; attempt++ {
+		// El body se consume al enviar, así que la petición se arma en cada intento
+		req, body, err := buildAPIRequest(config, request)
+		if err != nil {
+			return nil, err
+		}
+		debugf("%s %s (intento %d)", req.Method, req.URL, attempt+1)
+		debugf("body: %s", body)
+
+		recordRequest(req.URL)
+		resp, err := client.Do(req.WithContext(ctx))
+		if err != nil && ctx.Err() != nil {
+			// Cancelado por el usuario (Ctrl+C): no tiene sentido reintentar
+			return nil, fmt.Errorf("error en request HTTP: %v", ctx.Err())
+		}
+		if err != nil {
+			debugf("error: %v", err)
+		} else {
+			debugf("status: %s", resp.Status)
+		}
+		if err == nil && !isRetryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+		if attempt >= maxRetries {
+			if err != nil {
+				return nil, fmt.Errorf("error en request HTTP: %v", err)
+			}
+			return resp, nil
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("error en request HTTP: %v", ctx.Err())
+		case <-time.After(retryDelay(attempt, baseDelay)):
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package aiwrapper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsRetryableStatus(t *testing.T) {
+	tests := []struct {
+		code int
+		want bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusBadRequest, false},
+		{http.StatusUnauthorized, false},
+		{http.StatusNotFound, false},
+		{http.StatusTooManyRequests, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusBadGateway, true},
+		{http.StatusServiceUnavailable, true},
+		{http.StatusGatewayTimeout, true},
+	}
+	for _, tt := range tests {
+		if got := isRetryableStatus(tt.code); got != tt.want {
+			t.Errorf("isRetryableStatus(%d) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	base := 500 * time.Millisecond
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{0, base, 2 * base},
+		{1, 2 * base, 3 * base},
+		{3, 8 * base, 9 * base},
+		{10, maxRetryDelay, maxRetryDelay},
+		{64, maxRetryDelay, maxRetryDelay},
+		{1000, maxRetryDelay, maxRetryDelay},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempt, base); got < tt.min || got > tt.max {
+			t.Errorf("retryDelay(%d, %v) = %v, want between %v and %v", tt.attempt, base, got, tt.min, tt.max)
+		}
+	}
+	if got := retryDelay(1000, time.Hour); got != maxRetryDelay {
+		t.Errorf("retryDelay(1000, 1h) = %v, want %v", got, maxRetryDelay)
+	}
+}
+
+func TestSendWithRetry(t *testing.T) {
+	t.Setenv("AI_RETRY_BASE_DELAY_MS", "1")
+	statuses := []int{http.StatusTooManyRequests, http.StatusOK}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := statuses[len(statuses)-1]
+		if requests < len(statuses) {
+			status = statuses[requests]
+		}
+		requests++
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	config := AIConfig{Provider: "openai", BaseURL: server.URL, Model: "gpt-4o", PayloadStyle: payloadOpenAI, Timeout: 5 * time.Second}
+
+	tests := []struct {
+		maxRetries string
+		statuses   []int
+		wantStatus int
+		wantCalls  int
+	}{
+		{"2", []int{http.StatusTooManyRequests, http.StatusOK}, http.StatusOK, 2},
+		{"2", []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK}, http.StatusOK, 3},
+		{"1", []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK}, http.StatusServiceUnavailable, 2},
+		{"0", []int{http.StatusTooManyRequests, http.StatusOK}, http.StatusTooManyRequests, 1},
+		{"2", []int{http.StatusUnauthorized, http.StatusOK}, http.StatusUnauthorized, 1},
+	}
+	for _, tt := range tests {
+		t.Setenv("AI_MAX_RETRIES", tt.maxRetries)
+		statuses, requests = tt.statuses, 0
+		resp, err := sendWithRetry(context.Background(), config, AIRequest{Prompt: "listar archivos"})
+		if err != nil {
+			t.Fatalf("sendWithRetry(%v) error = %v", tt.statuses, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.wantStatus || requests != tt.wantCalls {
+			t.Errorf("sendWithRetry(%v) with AI_MAX_RETRIES=%s = %d after %d calls, want %d after %d",
+				tt.statuses, tt.maxRetries, resp.StatusCode, requests, tt.wantStatus, tt.wantCalls)
+		}
+	}
+}
+
+func TestSendWithRetryCanceled(t *testing.T) {
+	t.Setenv("AI_MAX_RETRIES", "5")
+	t.Setenv("AI_RETRY_BASE_DELAY_MS", "10000")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	config := AIConfig{Provider: "openai", BaseURL: server.URL, Model: "gpt-4o", PayloadStyle: payloadOpenAI, Timeout: 5 * time.Second}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := sendWithRetry(ctx, config, AIRequest{Prompt: "x"}); err == nil {
+		t.Error("sendWithRetry() with a canceled context error = nil")
+	}
+}
+
+func TestSendWithRetryRecordsRequestHost(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("AI_MAX_RETRIES", "0")
+	var calls int
+	server := newChatServer(t, http.StatusOK, "ls -la", &calls)
+
+	sessionRequests.Lock()
+	saved := sessionRequests.byHost
+	sessionRequests.byHost = nil
+	sessionRequests.Unlock()
+	defer func() {
+		sessionRequests.Lock()
+		sessionRequests.byHost = saved
+		sessionRequests.Unlock()
+	}()
+
+	if RequestCount() != 0 || len(RequestsByHost()) != 0 {
+		t.Fatalf("RequestCount() = %d before any request, want 0", RequestCount())
+	}
+
+	serverURL, _ := url.Parse(server.URL)
+	config := AIConfig{
+		Provider:     "openai",
+		BaseURL:      "http://usuario:clave-secreta@" + serverURL.Host + "/v1/chat/completions?key=sk-secreta",
+		Model:        "gpt-4o-mini",
+		APIKey:       "sk-test-0123456789abcdef",
+		AuthStyle:    authBearer,
+		PayloadStyle: payloadOpenAI,
+		Timeout:      5 * time.Second,
+	}
+	for i := 0; i < 2; i++ {
+		resp, err := sendWithRetry(context.Background(), config, AIRequest{Prompt: "listar archivos"})
+		if err != nil {
+			t.Fatalf("sendWithRetry() error = %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if got := RequestCount(); got != 2 {
+		t.Errorf("RequestCount() = %d, want 2", got)
+	}
+	hosts := RequestsByHost()
+	if len(hosts) != 1 || hosts[0].Host != serverURL.Host || hosts[0].Requests != 2 {
+		t.Errorf("RequestsByHost() = %+v, want 2 requests to %s", hosts, serverURL.Host)
+	}
+	recorded := fmt.Sprint(hosts)
+	for _, secret := range []string{"clave-secreta", "sk-secreta", "sk-test", "/v1/chat"} {
+		if strings.Contains(recorded, secret) {
+			t.Errorf("RequestsByHost() = %s, leaks %q", recorded, secret)
+		}
+	}
+}
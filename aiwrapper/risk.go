@@ -0,0 +1,88 @@
+package aiwrapper
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Umbrales del puntaje de riesgo
+const (
+	RiskExtremeThreshold  = 90 // con AI_EXTREME_CONFIRM, desde aquí se exige reescribir el comando
+	RiskStrictThreshold   = 70 // desde aquí se exige escribir "confirmar"
+	RiskAutoExecThreshold = 30 // desde aquí AI_AUTO_EXEC pide confirmación
+)
+
+// Clasificador de riesgo: peso que suma al puntaje y razón mostrada al usuario
+type riskCheck struct {
+	weight  int
+	reason  string
+	matches func(cmd string) bool
+}
+
+// networkCommands son binarios que hablan con la red
+var networkCommands = map[string]bool{
+	"curl": true, "wget": true, "nc": true, "ncat": true, "netcat": true, "ssh": true, "scp": true,
+	"sftp": true, "ftp": true, "rsync": true, "telnet": true, "socat": true,
+}
+
+// injectionRegex reconoce ejecución de código descargado o construido en tiempo de ejecución
+var injectionRegex = regexp.MustCompile(`\|\s*(sudo\s+)?(ba|z|da|k)?sh\b|\beval\b|\bsource\s+<\(|\b(ba)?sh\s+-c\s+"?\$\(`)
+
+// riskChecks son los clasificadores que forman el puntaje, del más al menos grave.
+// Un comando destructivo llega solo al umbral extremo para que AI_EXTREME_CONFIRM lo cubra.
+var riskChecks = []riskCheck{
+	{RiskExtremeThreshold, "destructivo", func(cmd string) bool { dangerous, _ := IsDangerous(cmd); return dangerous }},
+	{40, "ejecuta código descargado o dinámico", injectionRegex.MatchString},
+	{25, "usa privilegios de root", runsAsRoot},
+	{15, "usa la red", usesNetwork},
+	{10, "puede escribir archivos grandes", mayWriteLargeFiles},
+}
+
+// runsAsRoot verifica si el comando usa sudo/doas o requiere privilegios de root
+func runsAsRoot(cmd string) bool {
+	if NeedsRoot(cmd) {
+		return true
+	}
+	for _, field := range shellFields(cmd) {
+		if field == "sudo" || field == "doas" {
+			return true
+		}
+	}
+	return false
+}
+
+// usesNetwork verifica si algún binario del comando accede a la red
+func usesNetwork(cmd string) bool {
+	for _, field := range shellFields(cmd) {
+		if networkCommands[field] {
+			return true
+		}
+	}
+	return false
+}
+
+// RiskScore combina los clasificadores en un puntaje de 0 a 100 y las razones que aplican
+func RiskScore(cmd string) (int, []string) {
+	score := 0
+	var reasons []string
+	for _, check := range riskChecks {
+		if check.matches(cmd) {
+			score += check.weight
+			reasons = append(reasons, check.reason)
+		}
+	}
+	if score > 100 {
+		score = 100
+	}
+	return score, reasons
+}
+
+// FormatRisk arma la línea "Riesgo: 40/100 — usa privilegios de root, usa la red"
+func FormatRisk(score int, reasons []string) string {
+	line := fmt.Sprintf("Riesgo: %d/100", score)
+	if len(reasons) > 0 {
+		line += " — " + strings.Join(reasons, ", ")
+	}
+	return line
+}
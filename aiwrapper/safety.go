@@ -0,0 +1,258 @@
+package aiwrapper
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Patrón destructivo y la razón que se muestra al usuario
+type dangerousPattern struct {
+	pattern *regexp.Regexp
+	reason  string
+}
+
+// dangerousPatterns es la lista negra de comandos destructivos; agregar
+// una entrada aquí basta para detectar un patrón nuevo.
+var dangerousPatterns = []dangerousPattern{
+	// Las opciones pueden ser cortas (-f), largas (--no-preserve-root) o el separador --
+	{regexp.MustCompile(`\brm\s+(--?[\w-]*\s+)*(-\w*[rR]\w*|--recursive)\s+(--?[\w-]*\s+)*("?/\*?"?|~/?|\$HOME/?)(\s|$)`), "borrado recursivo de / o del directorio home"},
+	{regexp.MustCompile(`\bmkfs(\.\w+)?\b`), "formateo de un sistema de archivos"},
+	{regexp.MustCompile(`\bdd\s+.*\bif=`), "escritura de bajo nivel con dd"},
+	{regexp.MustCompile(`:\(\)\s*\{\s*:\s*\|\s*:\s*&\s*\}\s*;\s*:`), "fork bomb"},
+	{regexp.MustCompile(`>\s*/dev/(sd|hd|vd|xvd|nvme|mmcblk)\w*`), "sobrescritura directa de un disco"},
+	{regexp.MustCompile(`\bchmod\s+(-\w+\s+)*-R\s+(-\w+\s+)*0?777\s+/(\s|$)`), "permisos 777 recursivos sobre /"},
+}
+
+// IsDangerous verifica si el comando coincide con un patrón destructivo y devuelve la razón
+func IsDangerous(cmd string) (bool, string) {
+	for _, dangerous := range dangerousPatterns {
+		if dangerous.pattern.MatchString(cmd) {
+			return true, dangerous.reason
+		}
+	}
+	return false, ""
+}
+
+// HasChainedCommands verifica si el comando encadena varios comandos con ;, &, &&,
+// ||, saltos de línea o subshells ($(...) y backticks). Respeta las comillas:
+// dentro de comillas simples nada cuenta y dentro de dobles solo las subshells,
+// que sí se ejecutan. Un salto de línea escapado con \ continúa la línea.
+func HasChainedCommands(cmd string) bool {
+	var quote rune
+	escaped := false
+	runes := []rune(strings.TrimSpace(cmd))
+	for i, r := range runes {
+		prev, next := rune(0), rune(0)
+		if i > 0 {
+			prev = runes[i-1]
+		}
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			}
+		case r == '`' || r == '$' && next == '(':
+			return true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == ';', r == '\n', r == '&' && next == '&', r == '|' && next == '|':
+			return true
+		case r == '&' && prev != '>' && prev != '|' && next != '>':
+			// & suelto: el comando anterior va a segundo plano y sigue otro.
+			// No cuentan >&, &> ni |& (redirecciones)
+			return true
+		}
+	}
+	return false
+}
+
+// readOnlyCommands son los binarios que solo leen y se pueden ejecutar para
+// completar un prompt; cualquier otro se rechaza
+var readOnlyCommands = map[string]bool{
+	"ls": true, "cat": true, "head": true, "tail": true, "wc": true, "grep": true,
+	"df": true, "du": true, "free": true, "ps": true, "uptime": true, "pwd": true,
+	"whoami": true, "id": true, "uname": true, "which": true, "file": true, "stat": true,
+	"cut": true, "git": true,
+}
+
+// readOnlyGitSubcommands son los subcomandos de git que solo consultan el repositorio
+var readOnlyGitSubcommands = map[string]bool{
+	"status": true, "log": true, "diff": true, "show": true, "rev-parse": true, "describe": true,
+}
+
+// IsReadOnlyCommand verifica que el comando solo use binarios de readOnlyCommands,
+// unidos a lo sumo por tuberías, sin redirecciones, encadenamientos ni patrones
+// destructivos
+func IsReadOnlyCommand(cmd string) bool {
+	if dangerous, _ := IsDangerous(cmd); dangerous || HasChainedCommands(cmd) {
+		return false
+	}
+
+	fields := shellFields(cmd)
+	if len(fields) == 0 {
+		return false
+	}
+	start := 0
+	for i := 0; i <= len(fields); i++ {
+		if i < len(fields) && fields[i] != "|" {
+			if isShellOperator(fields[i]) {
+				return false
+			}
+			continue
+		}
+		if !isReadOnlySegment(fields[start:i]) {
+			return false
+		}
+		start = i + 1
+	}
+	return true
+}
+
+// isReadOnlySegment verifica un comando simple (sin operadores) de IsReadOnlyCommand
+func isReadOnlySegment(fields []string) bool {
+	if len(fields) == 0 || !readOnlyCommands[fields[0]] {
+		return false
+	}
+	for _, arg := range fields[1:] {
+		// git diff/log --output escriben un archivo
+		if strings.HasPrefix(arg, "--output") {
+			return false
+		}
+	}
+	if fields[0] == "git" {
+		return len(fields) > 1 && readOnlyGitSubcommands[fields[1]]
+	}
+	return true
+}
+
+// rootCommands son binarios que casi siempre requieren privilegios de root
+var rootCommands = map[string]bool{
+	"useradd": true, "userdel": true, "usermod": true, "groupadd": true, "groupdel": true,
+	"mount": true, "umount": true, "fdisk": true, "parted": true, "mkswap": true, "swapon": true,
+	"modprobe": true, "insmod": true, "rmmod": true, "iptables": true, "nft": true,
+	"reboot": true, "shutdown": true, "poweroff": true, "visudo": true, "chroot": true,
+}
+
+// packageManagerActions son subcomandos de gestores de paquetes que requieren root
+var packageManagerActions = map[string]map[string]bool{
+	"apt":     {"install": true, "remove": true, "purge": true, "update": true, "upgrade": true, "autoremove": true},
+	"apt-get": {"install": true, "remove": true, "purge": true, "update": true, "upgrade": true, "autoremove": true},
+	"dnf":     {"install": true, "remove": true, "update": true, "upgrade": true},
+	"yum":     {"install": true, "remove": true, "update": true},
+	"pacman":  {"-S": true, "-R": true, "-Syu": true, "-Rs": true},
+	"snap":    {"install": true, "remove": true},
+}
+
+// serviceActions son subcomandos de systemctl/service que modifican el sistema
+var serviceActions = map[string]bool{
+	"start": true, "stop": true, "restart": true, "reload": true,
+	"enable": true, "disable": true, "mask": true, "unmask": true, "daemon-reload": true,
+}
+
+// fileWriters son binarios que modifican los archivos que reciben como argumento
+var fileWriters = map[string]bool{
+	"cp": true, "mv": true, "rm": true, "tee": true, "touch": true, "mkdir": true, "rmdir": true,
+	"chmod": true, "chown": true, "ln": true, "install": true, "sed": true, "truncate": true,
+}
+
+// privilegedPaths son prefijos de rutas que solo root puede modificar
+var privilegedPaths = []string{"/etc", "/usr", "/boot", "/opt", "/sys", "/proc", "/var/lib", "/lib", "/bin", "/sbin", "/root"}
+
+// IsRoot verifica si el proceso se ejecuta como root
+func IsRoot() bool {
+	return os.Geteuid() == 0
+}
+
+// isPrivilegedPath verifica si la ruta está bajo un directorio de sistema
+func isPrivilegedPath(path string) bool {
+	if !filepath.IsAbs(path) {
+		return false
+	}
+	path = filepath.Clean(path)
+	for _, prefix := range privilegedPaths {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsRoot verifica si el comando probablemente falle sin privilegios de root
+func NeedsRoot(cmd string) bool {
+	fields := shellFields(cmd)
+
+	var binary string
+	var args []string
+	afterRedirect := false
+
+	for i := 0; i <= len(fields); i++ {
+		// Evaluar cada segmento al llegar a un separador o al final
+		if i == len(fields) || (isShellOperator(fields[i]) && !isRedirect(fields[i])) {
+			if segmentNeedsRoot(binary, args) {
+				return true
+			}
+			binary, args = "", nil
+			continue
+		}
+
+		field := fields[i]
+		switch {
+		case isRedirect(field):
+			afterRedirect = true
+		case afterRedirect:
+			// Redirección hacia una ruta de sistema
+			if strings.HasPrefix(fields[i-1], ">") && isPrivilegedPath(field) {
+				return true
+			}
+			afterRedirect = false
+		case binary == "":
+			binary = filepath.Base(field)
+		default:
+			args = append(args, field)
+		}
+	}
+	return false
+}
+
+// segmentNeedsRoot evalúa un comando simple (binario y argumentos)
+func segmentNeedsRoot(binary string, args []string) bool {
+	if binary == "" || binary == "sudo" || binary == "doas" {
+		return false
+	}
+	if rootCommands[binary] || strings.HasPrefix(binary, "mkfs") {
+		return true
+	}
+	if actions, ok := packageManagerActions[binary]; ok && len(args) > 0 && actions[args[0]] {
+		return true
+	}
+	if (binary == "systemctl" || binary == "service") && len(args) > 0 {
+		action := args[0]
+		if binary == "service" && len(args) > 1 {
+			action = args[1]
+		}
+		if serviceActions[action] {
+			return true
+		}
+	}
+	if fileWriters[binary] {
+		for _, arg := range args {
+			if isPrivilegedPath(arg) {
+				return true
+			}
+		}
+	}
+	return false
+}
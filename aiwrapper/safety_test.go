@@ -0,0 +1,133 @@
+package aiwrapper
+
+import "testing"
+
+func TestIsReadOnlyCommand(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want bool
+	}{
+		{"ls -la", true},
+		{"df -h", true},
+		{"ps aux | grep nginx", true},
+		{"git status", true},
+		{"git log --oneline -5", true},
+		{"cat 'notas; varias.txt'", true},
+		{"rm -rf ~", false},
+		{"rm -rf /", false},
+		{"kill -9 1", false},
+		{"git push --force", false},
+		{"git", false},
+		{"git diff --output=/etc/passwd", false},
+		{"ls; rm -rf ~", false},
+		{"ls && reboot", false},
+		{"cat $(rm -rf /)", false},
+		{"ls > archivo.txt", false},
+		{"ls | sh", false},
+		{"/tmp/ls", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsReadOnlyCommand(tt.cmd); got != tt.want {
+			t.Errorf("IsReadOnlyCommand(%q) = %v, want %v", tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestIsDangerous(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want bool
+	}{
+		{"rm -rf /", true},
+		{"rm -rf ~", true},
+		{"rm -fr $HOME/", true},
+		{"rm --no-preserve-root -rf /", true},
+		{"rm -rf -- /", true},
+		{"rm --recursive --force /", true},
+		{"sudo rm -r -f /*", true},
+		{"mkfs.ext4 /dev/sdb1", true},
+		{"dd if=/dev/zero of=/dev/sda", true},
+		{":(){ :|:& };:", true},
+		{"rm -rf ./build", false},
+		{"rm -rf -- ./tmp", false},
+		{"rm archivo.txt", false},
+		{"ls -la /", false},
+	}
+	for _, tt := range tests {
+		if got, _ := IsDangerous(tt.cmd); got != tt.want {
+			t.Errorf("IsDangerous(%q) = %v, want %v", tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestHasChainedCommands(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want bool
+	}{
+		{"ls -la", false},
+		{"ps aux | grep nginx", false},
+		{"ls; rm -rf ~", true},
+		{"make && make install", true},
+		{"test -f x || touch x", true},
+		{"echo $(whoami)", true},
+		{"echo `whoami`", true},
+		{"ls\nrm -rf ~", true},
+		{"ls\r\nreboot", true},
+		{"ls -la\n", false},
+		{"ls \\\n-la", false},
+		{"echo 'a; b && c'", false},
+		{"echo 'línea\notra'", false},
+		{"echo \"a; b\"", false},
+		{"echo \"línea\notra\"", false},
+		{"echo \"$(whoami)\"", true},
+		{"echo '$(whoami)'", false},
+		{"echo a\\;b", false},
+		{"ls & rm -rf ./proj", true},
+		{"ls & reboot", true},
+		{"sleep 10 &", true},
+		{"make > build.log 2>&1", false},
+		{"make &> build.log", false},
+		{"make &>> build.log", false},
+		{"make |& tee build.log", false},
+		{"echo 'a & b'", false},
+		{"echo \"a & b\"", false},
+		{"echo a\\&b", false},
+	}
+	for _, tt := range tests {
+		if got := HasChainedCommands(tt.cmd); got != tt.want {
+			t.Errorf("HasChainedCommands(%q) = %v, want %v", tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestNeedsRoot(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want bool
+	}{
+		{"ls -la /etc", false},
+		{"cat /etc/hosts", false},
+		{"apt install curl", true},
+		{"apt list --installed", false},
+		{"pacman -S git", true},
+		{"systemctl restart nginx", true},
+		{"systemctl status nginx", false},
+		{"service nginx reload", true},
+		{"mount /dev/sdb1 /mnt", true},
+		{"/sbin/reboot", true},
+		{"mkfs.ext4 /dev/sdb1", true},
+		{"cp nginx.conf /etc/nginx/", true},
+		{"cp nginx.conf ./backup/", false},
+		{"echo 127.0.0.1 local >> /etc/hosts", true},
+		{"cat /etc/hosts > hosts.bak", false},
+		{"ls && useradd pepe", true},
+		{"sudo apt install curl", false}, // ya usa sudo
+	}
+	for _, tt := range tests {
+		if got := NeedsRoot(tt.cmd); got != tt.want {
+			t.Errorf("NeedsRoot(%q) = %v, want %v", tt.cmd, got, tt.want)
+		}
+	}
+}
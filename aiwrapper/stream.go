@@ -0,0 +1,150 @@
+package aiwrapper
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Fragmento de una respuesta OpenAI en streaming (SSE)
+type OpenAIStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+}
+
+// supportsStreaming indica si el proveedor puede transmitir la respuesta por partes
+func supportsStreaming(config AIConfig) bool {
+	return config.PayloadStyle == payloadOpenAI || config.PayloadStyle == payloadOllama
+}
+
+// callAIAPIStream realiza la llamada a la IA escribiendo en out cada fragmento a
+// medida que llega. Los proveedores sin streaming responden completo y se escribe
+// de una vez. Los errores nunca incluyen la API key.
+func callAIAPIStream(ctx context.Context, request AIRequest, out io.Writer) (AIResponse, error) {
+	response, err := streamAIRequest(ctx, request, out)
+	return response, redactError(err)
+}
+
+// streamAIRequest realiza la llamada en streaming a la API de IA
+func streamAIRequest(ctx context.Context, request AIRequest, out io.Writer) (AIResponse, error) {
+	config := request.resolveConfig()
+	if !supportsStreaming(config) {
+		response, err := callAI(ctx, request)
+		if err == nil {
+			fmt.Fprint(out, response.Text)
+		}
+		return response, err
+	}
+
+	request.Stream = true
+	resp, err := sendWithRetry(ctx, config, request)
+	if err != nil {
+		return AIResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return AIResponse{}, fmt.Errorf("error HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	var response AIResponse
+	reportedTokens := -1
+	if config.PayloadStyle == payloadOpenAI {
+		response, err = readOpenAIStream(resp.Body, out)
+	} else {
+		response, reportedTokens, err = readOllamaStream(resp.Body, out)
+	}
+	if err != nil {
+		return response, err
+	}
+
+	system := request.System
+	if system == "" {
+		system = baseSystemPrompt()
+	}
+	response.TokensUsed = recordTokenUsage(config.Provider, EstimateTokens(concatPrompt(system, request.History, request.Prompt))+EstimateTokens(response.Text), reportedTokens)
+	if strings.TrimSpace(response.Text) == "" {
+		return response, emptyResponseError(response.FinishReason, "")
+	}
+	return response, nil
+}
+
+// readOpenAIStream lee eventos SSE "data: {...}" hasta "data: [DONE]"
+func readOpenAIStream(body io.Reader, out io.Writer) (AIResponse, error) {
+	var text strings.Builder
+	var response AIResponse
+
+	// El scanner junta las líneas que llegan partidas en varios paquetes
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "data:")
+		if !ok {
+			continue // líneas vacías, comentarios ":" y otros campos SSE
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk OpenAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return response, fmt.Errorf("error parseando stream OpenAI: %v", err)
+		}
+		for _, choice := range chunk.Choices {
+			text.WriteString(choice.Delta.Content)
+			fmt.Fprint(out, choice.Delta.Content)
+			if choice.FinishReason != "" {
+				response.FinishReason = choice.FinishReason
+			}
+		}
+	}
+	response.Text = text.String()
+	if err := scanner.Err(); err != nil {
+		return response, fmt.Errorf("error leyendo stream: %v", err)
+	}
+	return response, nil
+}
+
+// readOllamaStream lee un objeto JSON por línea hasta el que trae "done": true
+func readOllamaStream(body io.Reader, out io.Writer) (AIResponse, int, error) {
+	var text strings.Builder
+	var response AIResponse
+	reportedTokens := -1
+
+	decoder := json.NewDecoder(body)
+	for {
+		var chunk struct {
+			OllamaResponse
+			Done bool `json:"done"`
+		}
+		if err := decoder.Decode(&chunk); err == io.EOF {
+			break
+		} else if err != nil {
+			response.Text = text.String()
+			return response, reportedTokens, fmt.Errorf("error parseando stream Ollama: %v", err)
+		}
+
+		text.WriteString(chunk.Response)
+		fmt.Fprint(out, chunk.Response)
+		if chunk.Done {
+			response.FinishReason = chunk.DoneReason
+			if chunk.EvalCount > 0 {
+				reportedTokens = chunk.PromptEvalCount + chunk.EvalCount
+				response.PromptTokens = chunk.PromptEvalCount
+				response.CompletionTokens = chunk.EvalCount
+			}
+			break
+		}
+	}
+	response.Text = text.String()
+	return response, reportedTokens, nil
+}
@@ -0,0 +1,62 @@
+package aiwrapper
+
+import (
+	"os"
+	"runtime"
+	"strings"
+)
+
+// Sistemas destino de AI_TARGET_OS
+const (
+	targetUnix    = "unix"
+	targetWindows = "windows"
+)
+
+// windowsSystemPrompt es la instrucción base cuando el destino es Windows
+const windowsSystemPrompt = "Eres un asistente que convierte lenguaje natural a comandos de PowerShell para Windows. Responde SOLO con el comando, sin explicaciones."
+
+// windowsShellLanguages son las etiquetas de bloque que cuentan como comandos en Windows
+var windowsShellLanguages = map[string]bool{
+	"powershell": true,
+	"pwsh":       true,
+	"ps1":        true,
+	"ps":         true,
+	"cmd":        true,
+	"bat":        true,
+	"batch":      true,
+}
+
+// getTargetOS obtiene el sistema destino de AI_TARGET_OS; por defecto el del propio equipo
+func getTargetOS() string {
+	switch strings.ToLower(os.Getenv("AI_TARGET_OS")) {
+	case targetWindows:
+		return targetWindows
+	case targetUnix:
+		return targetUnix
+	}
+	if runtime.GOOS == "windows" {
+		return targetWindows
+	}
+	return targetUnix
+}
+
+// isShellLanguage indica si la etiqueta del bloque es un shell del sistema destino
+func isShellLanguage(language string) bool {
+	if getTargetOS() == targetWindows {
+		return language == "" || windowsShellLanguages[language]
+	}
+	return shellLanguages[language]
+}
+
+// ShellCommand devuelve el binario y los argumentos para ejecutar command en el sistema destino
+func ShellCommand(command string) (string, []string) {
+	if getTargetOS() == targetWindows {
+		return "powershell", []string{"-NoProfile", "-Command", command}
+	}
+	return userShell(), []string{"-c", command}
+}
+
+// userShell obtiene el shell del usuario desde $SHELL, con /bin/sh por defecto
+func userShell() string {
+	return getEnvOrDefault("SHELL", "/bin/sh")
+}
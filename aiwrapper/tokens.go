@@ -0,0 +1,88 @@
+package aiwrapper
+
+import (
+	"sort"
+	"sync"
+)
+
+// Tokens acumulados en la sesión para calibrar el estimador
+type TokenStats struct {
+	Requests          int // peticiones con respuesta
+	Estimated         int // tokens estimados de todas las peticiones
+	Reported          int // tokens reportados por los proveedores
+	ReportedRequests  int // peticiones en las que el proveedor reportó uso
+	EstimatedReported int // tokens estimados de las peticiones con uso reportado
+}
+
+// Uso acumulado de un proveedor en la sesión
+type ProviderUsage struct {
+	Provider string
+	Requests int
+	Tokens   int // reportados o, si el proveedor no los informa, estimados
+}
+
+// sessionTokens guarda las estadísticas de tokens de la sesión
+var sessionTokens struct {
+	sync.Mutex
+	stats      TokenStats
+	byProvider map[string]*ProviderUsage
+}
+
+// Add acumula una petición; reported < 0 indica que el proveedor no reportó uso
+func (ts *TokenStats) Add(estimated, reported int) {
+	ts.Requests++
+	ts.Estimated += estimated
+	if reported >= 0 {
+		ts.ReportedRequests++
+		ts.Reported += reported
+		ts.EstimatedReported += estimated
+	}
+}
+
+// Delta es la diferencia entre lo reportado y lo estimado para las mismas peticiones
+func (ts TokenStats) Delta() int {
+	return ts.Reported - ts.EstimatedReported
+}
+
+// recordTokenUsage acumula el uso de una petición en la sesión y devuelve los
+// tokens de la petición: los reportados por el proveedor o, si no hay, los estimados
+func recordTokenUsage(provider string, estimated, reported int) int {
+	sessionTokens.Lock()
+	defer sessionTokens.Unlock()
+	sessionTokens.stats.Add(estimated, reported)
+
+	tokens := estimated
+	if reported >= 0 {
+		tokens = reported
+	}
+	if sessionTokens.byProvider == nil {
+		sessionTokens.byProvider = make(map[string]*ProviderUsage)
+	}
+	usage, ok := sessionTokens.byProvider[provider]
+	if !ok {
+		usage = &ProviderUsage{Provider: provider}
+		sessionTokens.byProvider[provider] = usage
+	}
+	usage.Requests++
+	usage.Tokens += tokens
+	return tokens
+}
+
+// GetTokenStats devuelve una copia de las estadísticas de la sesión
+func GetTokenStats() TokenStats {
+	sessionTokens.Lock()
+	defer sessionTokens.Unlock()
+	return sessionTokens.stats
+}
+
+// UsageByProvider devuelve el uso acumulado de la sesión por proveedor, en orden alfabético
+func UsageByProvider() []ProviderUsage {
+	sessionTokens.Lock()
+	defer sessionTokens.Unlock()
+	usages := make([]ProviderUsage, 0, len(sessionTokens.byProvider))
+	for _, usage := range sessionTokens.byProvider {
+		usages = append(usages, *usage)
+	}
+	sort.Slice(usages, func(i, j int) bool { return usages[i].Provider < usages[j].Provider })
+	return usages
+}
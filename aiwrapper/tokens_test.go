@@ -0,0 +1,57 @@
+package aiwrapper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenStatsAdd(t *testing.T) {
+	var stats TokenStats
+	stats.Add(100, 120)
+	stats.Add(50, -1)
+	stats.Add(30, 20)
+
+	want := TokenStats{Requests: 3, Estimated: 180, Reported: 140, ReportedRequests: 2, EstimatedReported: 130}
+	if stats != want {
+		t.Errorf("TokenStats after Add = %+v, want %+v", stats, want)
+	}
+	// Solo se comparan las peticiones con uso reportado: 140 - 130
+	if got := stats.Delta(); got != 10 {
+		t.Errorf("Delta() = %d, want 10", got)
+	}
+	if got := (TokenStats{Requests: 1, Estimated: 40}).Delta(); got != 0 {
+		t.Errorf("Delta() without reported usage = %d, want 0", got)
+	}
+}
+
+func TestRecordTokenUsage(t *testing.T) {
+	sessionTokens.Lock()
+	savedStats, savedByProvider := sessionTokens.stats, sessionTokens.byProvider
+	sessionTokens.stats, sessionTokens.byProvider = TokenStats{}, nil
+	sessionTokens.Unlock()
+	defer func() {
+		sessionTokens.Lock()
+		sessionTokens.stats, sessionTokens.byProvider = savedStats, savedByProvider
+		sessionTokens.Unlock()
+	}()
+
+	if got := recordTokenUsage("openai", 100, 120); got != 120 {
+		t.Errorf("recordTokenUsage(openai, 100, 120) = %d, want the reported 120", got)
+	}
+	if got := recordTokenUsage("ollama", 50, -1); got != 50 {
+		t.Errorf("recordTokenUsage(ollama, 50, -1) = %d, want the estimated 50", got)
+	}
+	recordTokenUsage("openai", 10, 15)
+
+	want := []ProviderUsage{
+		{Provider: "ollama", Requests: 1, Tokens: 50},
+		{Provider: "openai", Requests: 2, Tokens: 135},
+	}
+	if got := UsageByProvider(); !reflect.DeepEqual(got, want) {
+		t.Errorf("UsageByProvider() = %+v, want %+v", got, want)
+	}
+	stats := GetTokenStats()
+	if stats.Requests != 3 || stats.Estimated != 160 || stats.Reported != 135 || stats.Delta() != 25 {
+		t.Errorf("GetTokenStats() = %+v, want 3 requests, 160 estimated, 135 reported, delta 25", stats)
+	}
+}
@@ -0,0 +1,54 @@
+package aiwrapper
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// verifySystemPrompt pide juzgar si un comando cumple la petición original
+const verifySystemPrompt = "Eres un revisor de comandos de Unix/Linux. Indica si el comando cumple la petición. Responde en la primera línea solo \"sí\" o \"no\", y en la siguiente una explicación breve."
+
+// verifyMaxTokens deja espacio para la explicación además del sí/no
+const verifyMaxTokens = 150
+
+// Resultado de verificar un comando contra la petición original
+type VerifyResult struct {
+	Matches bool
+	Known   bool // false si la respuesta no empezaba con sí/no reconocible
+	Reason  string
+}
+
+// parseVerifyResponse extrae el veredicto sí/no y la explicación de la respuesta
+func parseVerifyResponse(raw string) VerifyResult {
+	text := strings.TrimSpace(raw)
+	verdict, reason, _ := strings.Cut(text, "\n")
+	if reason == "" {
+		// Veredicto y explicación en la misma línea: "Sí, porque ..."
+		if i := strings.IndexAny(verdict, ",.:;-"); i >= 0 {
+			verdict, reason = verdict[:i], verdict[i+1:]
+		}
+	}
+
+	result := VerifyResult{Reason: strings.TrimSpace(reason)}
+	word := strings.ToLower(strings.Trim(strings.TrimSpace(verdict), "*`\"'¡!"))
+	switch word {
+	case "sí", "si", "yes", "y":
+		result.Matches, result.Known = true, true
+	case "no", "n":
+		result.Known = true
+	default:
+		result.Reason = text
+	}
+	return result
+}
+
+// VerifyCommand hace una segunda llamada a la IA para comprobar que el comando cumple la petición
+func VerifyCommand(ctx context.Context, userText, command string) (VerifyResult, error) {
+	prompt := fmt.Sprintf("¿Este comando cumple: %s?\nComando: %s", userText, command)
+	rawResponse, err := CallAIAPI(ctx, AIRequest{System: verifySystemPrompt, Prompt: prompt, MaxTokens: verifyMaxTokens})
+	if err != nil {
+		return VerifyResult{}, fmt.Errorf("no se pudo verificar el comando: %v", err)
+	}
+	return parseVerifyResponse(cleanResponse(rawResponse)), nil
+}
@@ -0,0 +1,66 @@
+package aiwrapper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParseVerifyResponse(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want VerifyResult
+	}{
+		{"sí\nLista los archivos ocultos.", VerifyResult{Matches: true, Known: true, Reason: "Lista los archivos ocultos."}},
+		{"**Sí**, lista los archivos.", VerifyResult{Matches: true, Known: true, Reason: "lista los archivos."}},
+		{"Yes", VerifyResult{Matches: true, Known: true}},
+		{"No\nFalta la opción -a.", VerifyResult{Known: true, Reason: "Falta la opción -a."}},
+		{"no: borra en lugar de listar", VerifyResult{Known: true, Reason: "borra en lugar de listar"}},
+		{"Depende del sistema", VerifyResult{Reason: "Depende del sistema"}},
+		{"", VerifyResult{}},
+	}
+	for _, tt := range tests {
+		if got := parseVerifyResponse(tt.raw); got != tt.want {
+			t.Errorf("parseVerifyResponse(%q) = %+v, want %+v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyCommandWithTransport(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("AI_PROVIDER", "openai")
+	t.Setenv("AI_BASE_URL", "")
+	t.Setenv("AI_MODEL", "gpt-4o-mini")
+	t.Setenv("AI_API_KEY", "sk-test-0123456789abcdef")
+	t.Setenv("AI_FALLBACK_PROVIDER", "")
+
+	var gotBody string
+	SetHTTPTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(req.Body)
+		gotBody = string(body)
+		response := `{"choices":[{"message":{"content":"no\nFalta incluir los archivos ocultos."},"finish_reason":"stop"}]}`
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(response)),
+			Request:    req,
+		}, nil
+	}))
+	defer SetHTTPTransport(nil)
+
+	result, err := VerifyCommand(context.Background(), "listar todos los archivos", "ls")
+	if err != nil {
+		t.Fatalf("VerifyCommand() error = %v", err)
+	}
+	want := VerifyResult{Known: true, Reason: "Falta incluir los archivos ocultos."}
+	if result != want {
+		t.Errorf("VerifyCommand() = %+v, want %+v", result, want)
+	}
+	for _, fragment := range []string{"listar todos los archivos", "Comando: ls", "revisor de comandos"} {
+		if !strings.Contains(gotBody, fragment) {
+			t.Errorf("request body = %s, missing %q", gotBody, fragment)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package aiwrapper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// workflowSystemPrompt pide varios comandos con sus dependencias en JSON
+const workflowSystemPrompt = "Eres un asistente que convierte tareas en varios comandos de Unix/Linux. Responde SOLO con un arreglo JSON de pasos con el formato [{\"cmd\": \"<comando>\", \"after\": [<índices de los pasos que deben ejecutarse antes>]}], con índices desde 0. Sin explicaciones."
+
+// workflowMaxTokens deja espacio para varios comandos en JSON
+const workflowMaxTokens = 600
+
+// Paso de un workflow: un comando y los índices de los pasos de los que depende
+type WorkflowStep struct {
+	Command string `json:"cmd"`
+	After   []int  `json:"after"`
+}
+
+// Workflow es una lista de comandos con dependencias explícitas
+type Workflow []WorkflowStep
+
+// parseWorkflow extrae y valida el arreglo JSON de pasos de la respuesta de la IA
+func parseWorkflow(raw string) (Workflow, error) {
+	start, end := strings.Index(raw, "["), strings.LastIndex(raw, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("la respuesta no contiene un arreglo JSON de pasos")
+	}
+
+	var workflow Workflow
+	if err := json.Unmarshal([]byte(raw[start:end+1]), &workflow); err != nil {
+		return nil, fmt.Errorf("error parseando workflow: %v", err)
+	}
+	if len(workflow) == 0 {
+		return nil, fmt.Errorf("el workflow no tiene pasos")
+	}
+	for i, step := range workflow {
+		if strings.TrimSpace(step.Command) == "" {
+			return nil, fmt.Errorf("el paso %d no tiene comando", i)
+		}
+		for _, dep := range step.After {
+			if dep < 0 || dep >= len(workflow) {
+				return nil, fmt.Errorf("el paso %d depende de un paso inexistente: %d", i, dep)
+			}
+		}
+	}
+	return workflow, nil
+}
+
+// Order devuelve los índices de los pasos en orden topológico; entre pasos
+// independientes se respeta el orden original. Falla si hay un ciclo.
+func (w Workflow) Order() ([]int, error) {
+	pending := make([]int, len(w))
+	dependents := make([][]int, len(w))
+	for i, step := range w {
+		for _, dep := range step.After {
+			pending[i]++
+			dependents[dep] = append(dependents[dep], i)
+		}
+	}
+
+	var ready []int
+	for i := range w {
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	order := make([]int, 0, len(w))
+	for len(ready) > 0 {
+		sort.Ints(ready)
+		next := ready[0]
+		ready = ready[1:]
+		order = append(order, next)
+		for _, dependent := range dependents[next] {
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	if len(order) < len(w) {
+		var cycle []string
+		for i := range w {
+			if pending[i] > 0 {
+				cycle = append(cycle, fmt.Sprint(i))
+			}
+		}
+		return nil, fmt.Errorf("dependencias circulares entre los pasos %s", strings.Join(cycle, ", "))
+	}
+	return order, nil
+}
+
+// TranslateWorkflow pide a la IA un workflow de varios comandos para userText
+func TranslateWorkflow(ctx context.Context, userText string) (string, Workflow, error) {
+	rawResponse, err := CallAIAPI(ctx, AIRequest{System: workflowSystemPrompt, Prompt: userText, MaxTokens: workflowMaxTokens})
+	if err != nil {
+		return "", nil, aiCallError(err)
+	}
+	rawResponse = cleanResponse(rawResponse)
+
+	workflow, err := parseWorkflow(rawResponse)
+	if err != nil {
+		return rawResponse, nil, err
+	}
+	return rawResponse, workflow, nil
+}
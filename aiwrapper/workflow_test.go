@@ -0,0 +1,61 @@
+package aiwrapper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseWorkflow(t *testing.T) {
+	raw := "Aquí está el plan:\n```json\n[{\"cmd\": \"mkdir -p build\"}, {\"cmd\": \"make\", \"after\": [0]}]\n```"
+	workflow, err := parseWorkflow(raw)
+	if err != nil {
+		t.Fatalf("parseWorkflow() error = %v", err)
+	}
+	want := Workflow{{Command: "mkdir -p build"}, {Command: "make", After: []int{0}}}
+	if !reflect.DeepEqual(workflow, want) {
+		t.Errorf("parseWorkflow() = %+v, want %+v", workflow, want)
+	}
+
+	errors := []struct {
+		raw, want string
+	}{
+		{"no hay pasos", "no contiene un arreglo JSON"},
+		{"[]", "no tiene pasos"},
+		{`[{"cmd": "ls"`, "no contiene un arreglo JSON"},
+		{`[{"cmd": "ls", "after": "0"}]`, "error parseando workflow"},
+		{`[{"cmd": "  "}]`, "el paso 0 no tiene comando"},
+		{`[{"cmd": "ls", "after": [3]}]`, "paso inexistente: 3"},
+	}
+	for _, tt := range errors {
+		if _, err := parseWorkflow(tt.raw); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseWorkflow(%q) error = %v, want %q", tt.raw, err, tt.want)
+		}
+	}
+}
+
+func TestWorkflowOrder(t *testing.T) {
+	tests := []struct {
+		workflow Workflow
+		want     []int
+	}{
+		{Workflow{{Command: "a"}, {Command: "b"}, {Command: "c"}}, []int{0, 1, 2}},
+		{Workflow{{Command: "a", After: []int{2}}, {Command: "b"}, {Command: "c"}}, []int{1, 2, 0}},
+		{Workflow{{Command: "a", After: []int{1}}, {Command: "b", After: []int{2}}, {Command: "c"}}, []int{2, 1, 0}},
+		{Workflow{{Command: "a"}, {Command: "b", After: []int{0}}, {Command: "c", After: []int{0}}, {Command: "d", After: []int{1, 2}}}, []int{0, 1, 2, 3}},
+	}
+	for _, tt := range tests {
+		got, err := tt.workflow.Order()
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Order(%+v) = %v, %v, want %v", tt.workflow, got, err, tt.want)
+		}
+	}
+}
+
+func TestWorkflowOrderCycle(t *testing.T) {
+	workflow := Workflow{{Command: "a"}, {Command: "b", After: []int{2}}, {Command: "c", After: []int{1}}}
+	_, err := workflow.Order()
+	if err == nil || !strings.Contains(err.Error(), "dependencias circulares entre los pasos 1, 2") {
+		t.Errorf("Order() error = %v, want cycle between steps 1 and 2", err)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/EmilianoMAl/AI-Wrapper/aiwrapper"
+)
+
+// defaultBanner es el texto de bienvenida cuando AI_BANNER no está definida
+const defaultBanner = "Mini-shell asistido por IA\nEscribe 'exit' o 'quit' para salir"
+
+// firstRunTips se muestran solo la primera vez que se ejecuta el shell
+var firstRunTips = []string{
+	"teach <petición> explica cada flag del comando generado",
+	"fix <comando> corrige un comando que falló",
+	"script on acumula los comandos generados en un script",
+	"@nombre expande macros definidas en AI_MACROS",
+	"NO_COLOR desactiva los colores",
+}
+
+// firstRunMarker es el archivo que indica que los consejos ya se mostraron
+const firstRunMarker = "first_run_done"
+
+// getBanner obtiene el banner a mostrar; AI_BANNER vacía lo desactiva
+func getBanner() string {
+	if banner, ok := os.LookupEnv("AI_BANNER"); ok {
+		return banner
+	}
+	return defaultBanner
+}
+
+// firstRun verifica si es la primera ejecución (no existe el marcador en dir)
+func firstRun(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, firstRunMarker))
+	return os.IsNotExist(err)
+}
+
+// markFirstRunDone crea el marcador para no volver a mostrar los consejos
+func markFirstRunDone(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, firstRunMarker), nil, 0644)
+}
+
+// printFirstRunTips muestra los consejos iniciales una sola vez
+func printFirstRunTips() {
+	dir, err := aiwrapper.ConfigDir()
+	if err != nil || !firstRun(dir) {
+		return
+	}
+
+	fmt.Println("Consejos:")
+	for _, tip := range firstRunTips {
+		fmt.Printf("  • %s\n", tip)
+	}
+	fmt.Println()
+
+	// Si no se puede guardar el marcador, se volverán a mostrar; no es crítico
+	markFirstRunDone(dir)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/EmilianoMAl/AI-Wrapper/aiwrapper"
+)
+
+func TestGetBanner(t *testing.T) {
+	// t.Setenv restaura el valor original al terminar
+	t.Setenv("AI_BANNER", "")
+	os.Unsetenv("AI_BANNER")
+	if got := getBanner(); got != defaultBanner {
+		t.Errorf("getBanner() without AI_BANNER = %q, want the default banner", got)
+	}
+
+	tests := []struct {
+		value, want string
+	}{
+		{"", ""},
+		{"neri listo", "neri listo"},
+	}
+	for _, tt := range tests {
+		t.Setenv("AI_BANNER", tt.value)
+		if got := getBanner(); got != tt.want {
+			t.Errorf("getBanner() with AI_BANNER=%q = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFirstRunMarker(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "neri")
+	if !firstRun(dir) {
+		t.Fatal("firstRun() = false before the marker exists")
+	}
+	if err := markFirstRunDone(dir); err != nil {
+		t.Fatalf("markFirstRunDone() error = %v", err)
+	}
+	if firstRun(dir) {
+		t.Error("firstRun() = true after markFirstRunDone()")
+	}
+}
+
+func TestPrintFirstRunTipsOnce(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	dir, err := aiwrapper.ConfigDir()
+	if err != nil {
+		t.Skipf("ConfigDir: %v", err)
+	}
+	if !firstRun(dir) {
+		t.Fatalf("firstRun(%s) = false with a fresh HOME", dir)
+	}
+	printFirstRunTips()
+	if firstRun(dir) {
+		t.Errorf("printFirstRunTips() did not create the marker in %s", dir)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/EmilianoMAl/AI-Wrapper/aiwrapper"
+)
+
+// Códigos ANSI usados por el shell
+const (
+	colorReset   = "\x1b[0m"
+	colorBold    = "\x1b[1m"
+	colorRed     = "\x1b[31m"
+	colorGreen   = "\x1b[32m"
+	colorYellow  = "\x1b[33m"
+	colorMagenta = "\x1b[35m"
+	colorCyan    = "\x1b[36m"
+)
+
+// isTerminal verifica si el archivo es una terminal (dispositivo de caracteres)
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorEnabled indica si se debe colorear la salida (respeta NO_COLOR)
+func colorEnabled() bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	return isTerminal(os.Stdout)
+}
+
+// colorize aplica el color si la salida lo admite
+func colorize(color, text string) string {
+	if !colorEnabled() {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// printWarning muestra una advertencia en amarillo
+func printWarning(format string, args ...interface{}) {
+	fmt.Println(colorize(colorYellow, fmt.Sprintf(format, args...)))
+}
+
+// printError muestra un error en rojo
+func printError(format string, args ...interface{}) {
+	fmt.Println(colorize(colorRed, fmt.Sprintf(format, args...)))
+}
+
+// highlightCommand colorea binario, flags, cadenas y operadores de un comando
+func highlightCommand(cmd string) string {
+	var out strings.Builder
+	expectBinary := true
+
+	for _, segment := range aiwrapper.CommandSegments(cmd) {
+		switch {
+		case segment.Kind == aiwrapper.SegmentSpace:
+			out.WriteString(segment.Text)
+		case segment.Kind == aiwrapper.SegmentOperator || segment.Kind == aiwrapper.SegmentRedirect:
+			out.WriteString(colorCyan + segment.Text + colorReset)
+			// Tras una redirección viene una ruta, no un binario
+			expectBinary = segment.Kind == aiwrapper.SegmentOperator
+		case segment.Kind == aiwrapper.SegmentQuoted:
+			out.WriteString(colorMagenta + segment.Text + colorReset)
+		case expectBinary:
+			out.WriteString(colorBold + colorGreen + segment.Text + colorReset)
+			expectBinary = false
+		case strings.HasPrefix(segment.Text, "-"):
+			out.WriteString(colorYellow + segment.Text + colorReset)
+		default:
+			out.WriteString(segment.Text)
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestHighlightCommand(t *testing.T) {
+	tests := []struct {
+		cmd, want string
+	}{
+		{"ls", colorBold + colorGreen + "ls" + colorReset},
+		{"ls -la /tmp",
+			colorBold + colorGreen + "ls" + colorReset + " " + colorYellow + "-la" + colorReset + " /tmp"},
+		{`grep -r "hola mundo" .`,
+			colorBold + colorGreen + "grep" + colorReset + " " + colorYellow + "-r" + colorReset + " " +
+				colorMagenta + `"hola mundo"` + colorReset + " ."},
+		{"ps aux | grep nginx",
+			colorBold + colorGreen + "ps" + colorReset + " aux " + colorCyan + "|" + colorReset + " " +
+				colorBold + colorGreen + "grep" + colorReset + " nginx"},
+		{"echo hola > salida.txt",
+			colorBold + colorGreen + "echo" + colorReset + " hola " + colorCyan + ">" + colorReset + " salida.txt"},
+	}
+	for _, tt := range tests {
+		if got := highlightCommand(tt.cmd); got != tt.want {
+			t.Errorf("highlightCommand(%q) = %q, want %q", tt.cmd, got, tt.want)
+		}
+	}
+}
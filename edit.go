@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode"
+)
+
+// Teclas de control reconocidas por el editor de línea
+const (
+	keyCtrlA     = 0x01
+	keyCtrlC     = 0x03
+	keyCtrlE     = 0x05
+	keyBackspace = 0x08
+	keyCtrlK     = 0x0b
+	keyCtrlU     = 0x15
+	keyEscape    = 0x1b
+	keyDelete    = 0x7f
+)
+
+// stty ejecuta stty sobre la terminal de stdin y devuelve su salida
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	output, err := cmd.Output()
+	return strings.TrimSpace(string(output)), err
+}
+
+// editLine muestra prompt con text precargado para editarlo en modo raw.
+// Enter acepta, Ctrl+C descarta los cambios. Devuelve false si la terminal no
+// admite modo raw; en ese caso no se leyó nada.
+func editLine(reader *bufio.Reader, prompt, text string) (string, bool) {
+	if !isTerminal(os.Stdin) {
+		return text, false
+	}
+	state, err := stty("-g")
+	if err != nil {
+		return text, false
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return text, false
+	}
+	defer stty(state)
+
+	line := []rune(text)
+	cursor := len(line)
+	render := func() {
+		fmt.Printf("\r\x1b[K%s%s", prompt, string(line))
+		if back := len(line) - cursor; back > 0 {
+			fmt.Printf("\x1b[%dD", back)
+		}
+	}
+
+	render()
+	for {
+		r, _, err := reader.ReadRune()
+		if err != nil {
+			fmt.Print("\r\n")
+			return text, true
+		}
+		switch r {
+		case '\r', '\n':
+			fmt.Print("\r\n")
+			return strings.TrimSpace(string(line)), true
+		case keyCtrlC:
+			fmt.Print("\r\n")
+			return text, true
+		case keyDelete, keyBackspace:
+			if cursor > 0 {
+				line = append(line[:cursor-1], line[cursor:]...)
+				cursor--
+			}
+		case keyCtrlA:
+			cursor = 0
+		case keyCtrlE:
+			cursor = len(line)
+		case keyCtrlK:
+			line = line[:cursor]
+		case keyCtrlU:
+			line = line[cursor:]
+			cursor = 0
+		case keyEscape:
+			line, cursor = editEscape(reader, line, cursor)
+		default:
+			if unicode.IsPrint(r) {
+				line = append(line[:cursor], append([]rune{r}, line[cursor:]...)...)
+				cursor++
+			}
+		}
+		render()
+	}
+}
+
+// editEscape aplica una secuencia de escape (flechas, Inicio, Fin, Supr) a la línea
+func editEscape(reader *bufio.Reader, line []rune, cursor int) ([]rune, int) {
+	if next, _, err := reader.ReadRune(); err != nil || next != '[' {
+		return line, cursor
+	}
+	key, _, err := reader.ReadRune()
+	if err != nil {
+		return line, cursor
+	}
+	switch key {
+	case 'C':
+		if cursor < len(line) {
+			cursor++
+		}
+	case 'D':
+		if cursor > 0 {
+			cursor--
+		}
+	case 'H':
+		cursor = 0
+	case 'F':
+		cursor = len(line)
+	case '3':
+		// Supr llega como ESC [ 3 ~
+		if tilde, _, err := reader.ReadRune(); err == nil && tilde == '~' && cursor < len(line) {
+			line = append(line[:cursor], line[cursor+1:]...)
+		}
+	}
+	return line, cursor
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/EmilianoMAl/AI-Wrapper/aiwrapper"
+)
+
+// confirmExecution pregunta si ejecutar el comando; solo "y"/"yes" confirma
+func (ms *MiniShell) confirmExecution(reader *bufio.Reader) bool {
+	fmt.Print("Ejecutar? [y/N]: ")
+	answer, err := reader.ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// confirmWithRisk muestra el puntaje de riesgo y pide confirmación acorde a él:
+// desde aiwrapper.RiskStrictThreshold hay que escribir "confirmar", salvo que el comando
+// ya se haya confirmado así como peligroso, y con AI_EXTREME_CONFIRM desde
+// aiwrapper.RiskExtremeThreshold hay que reescribir el comando completo.
+func (ms *MiniShell) confirmWithRisk(reader *bufio.Reader, command string, confirmedDangerous bool) bool {
+	score, reasons := aiwrapper.RiskScore(command)
+	if score > 0 {
+		color := colorYellow
+		if score >= aiwrapper.RiskStrictThreshold {
+			color = colorRed
+		}
+		fmt.Println(colorize(color, aiwrapper.FormatRisk(score, reasons)))
+	}
+
+	if score >= aiwrapper.RiskExtremeThreshold && aiwrapper.GetEnvBool("AI_EXTREME_CONFIRM", false) {
+		fmt.Printf("Escribe el comando exacto para confirmar:\n  %s\n> ", command)
+		answer, err := reader.ReadString('\n')
+		return err == nil && retypeMatches(answer, command)
+	}
+	if score >= aiwrapper.RiskStrictThreshold && !confirmedDangerous {
+		fmt.Print("Escribe 'confirmar' para ejecutar: ")
+		answer, err := reader.ReadString('\n')
+		return err == nil && strings.TrimSpace(answer) == "confirmar"
+	}
+	return ms.confirmExecution(reader)
+}
+
+// retypeMatches compara el comando reescrito con el original; solo se ignoran
+// los espacios al inicio y al final
+func retypeMatches(typed, command string) bool {
+	return strings.TrimSpace(typed) == strings.TrimSpace(command)
+}
+
+// confirmDangerous muestra la advertencia y exige escribir "confirmar" para continuar
+func (ms *MiniShell) confirmDangerous(reader *bufio.Reader, command, reason string) bool {
+	fmt.Println(colorize(colorRed, fmt.Sprintf("⚠️  COMANDO PELIGROSO (%s): %s", reason, command)))
+	if !isTerminal(os.Stdin) {
+		return false
+	}
+
+	fmt.Print("Escribe 'confirmar' para continuar: ")
+	answer, err := reader.ReadString('\n')
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(answer) == "confirmar"
+}
+
+// executeCommand ejecuta el comando con $SHELL -c (powershell -Command en Windows) conectado a la terminal.
+// Devuelve el código de salida; -1 si el proceso terminó por una señal.
+func (ms *MiniShell) executeCommand(command string) (int, error) {
+	shell, args := aiwrapper.ShellCommand(command)
+	cmd := exec.Command(shell, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return -1, fmt.Errorf("no se pudo iniciar el comando: %v", err)
+	}
+
+	// Registrar el proceso para que Ctrl+C lo interrumpa a él y no al shell
+	ms.setChild(cmd.Process)
+	defer ms.setChild(nil)
+
+	err := cmd.Wait()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return -1, err
+	}
+	return cmd.ProcessState.ExitCode(), nil
+}
+
+// setChild registra el proceso hijo en ejecución (nil al terminar)
+func (ms *MiniShell) setChild(process *os.Process) {
+	ms.childMu.Lock()
+	defer ms.childMu.Unlock()
+	ms.child = process
+}
+
+// interruptChild envía SIGINT al proceso hijo, si hay uno en ejecución
+func (ms *MiniShell) interruptChild() bool {
+	ms.childMu.Lock()
+	defer ms.childMu.Unlock()
+	if ms.child == nil {
+		return false
+	}
+	ms.child.Signal(os.Interrupt)
+	return true
+}
+
+// requestContext crea el contexto de una petición a la IA, cancelable con Ctrl+C,
+// y muestra el spinner mientras dura. done debe llamarse al terminar la petición.
+func (ms *MiniShell) requestContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	spinner := startSpinner("Consultando a la IA...")
+	ms.childMu.Lock()
+	ms.cancelRequest = cancel
+	ms.requestCanceled = false
+	ms.spinner = spinner
+	ms.childMu.Unlock()
+
+	done := func() {
+		ms.stopSpinner()
+		ms.childMu.Lock()
+		ms.cancelRequest = nil
+		ms.childMu.Unlock()
+		cancel()
+	}
+	return ctx, done
+}
+
+// stopSpinner detiene el spinner de la petición en curso, si hay uno
+func (ms *MiniShell) stopSpinner() {
+	ms.childMu.Lock()
+	spinner := ms.spinner
+	ms.spinner = nil
+	ms.childMu.Unlock()
+	spinner.Stop()
+}
+
+// interruptRequest cancela la petición a la IA en curso, si hay una
+func (ms *MiniShell) interruptRequest() bool {
+	ms.childMu.Lock()
+	defer ms.childMu.Unlock()
+	if ms.cancelRequest == nil {
+		return false
+	}
+	// Detener el spinner antes de que se muestre "^C"
+	ms.spinner.Stop()
+	ms.spinner = nil
+	ms.cancelRequest()
+	ms.cancelRequest = nil
+	ms.requestCanceled = true
+	return true
+}
+
+// wasCanceled indica si la última petición a la IA se canceló con Ctrl+C
+func (ms *MiniShell) wasCanceled() bool {
+	ms.childMu.Lock()
+	defer ms.childMu.Unlock()
+	return ms.requestCanceled
+}
+
+// autoExecBlockReason devuelve por qué el comando no debe ejecutarse sin confirmación, o ""
+func autoExecBlockReason(command, rawResponse string) string {
+	if dangerous, reason := aiwrapper.IsDangerous(command); dangerous {
+		return reason
+	}
+	if aiwrapper.HasChainedCommands(command) {
+		return "el comando encadena varios comandos"
+	}
+	if score, reasons := aiwrapper.RiskScore(command); score >= aiwrapper.RiskAutoExecThreshold {
+		return strings.ToLower(aiwrapper.FormatRisk(score, reasons))
+	}
+	if language := aiwrapper.ParseCommandInfo(rawResponse).Language; language != "" {
+		return fmt.Sprintf("la respuesta es código %s", language)
+	}
+	if mismatch := aiwrapper.DomainMismatch(command, aiwrapper.GetDomain()); mismatch != "" {
+		return mismatch
+	}
+	if aiwrapper.LooksTruncated(command) {
+		return "el comando parece incompleto"
+	}
+	if len(aiwrapper.ExtractPlaceholders(command)) > 0 {
+		return "el comando tiene marcadores sin completar"
+	}
+	return ""
+}
+
+// runCommand ejecuta el comando y muestra su código de salida
+func (ms *MiniShell) runCommand(command string) {
+	ms.lastAccepted = command
+	exitCode, err := ms.executeCommand(command)
+	if err != nil {
+		printError("Error ejecutando comando: %v", err)
+		return
+	}
+	fmt.Printf("Código de salida: %d\n", exitCode)
+}
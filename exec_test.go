@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestRetypeMatches(t *testing.T) {
+	command := "rm -rf /var/tmp/cache"
+	tests := []struct {
+		typed string
+		want  bool
+	}{
+		{"rm -rf /var/tmp/cache\n", true},
+		{"  rm -rf /var/tmp/cache  \n", true},
+		{"rm -rf /var/tmp/cache/\n", false},
+		{"rm  -rf /var/tmp/cache\n", false},
+		{"RM -RF /var/tmp/cache\n", false},
+		{"confirmar\n", false},
+		{"\n", false},
+	}
+	for _, tt := range tests {
+		if got := retypeMatches(tt.typed, command); got != tt.want {
+			t.Errorf("retypeMatches(%q) = %v, want %v", tt.typed, got, tt.want)
+		}
+	}
+}
@@ -1,3 +1,3 @@
-module mini-shell-ia
+module github.com/EmilianoMAl/AI-Wrapper
 
 go 1.21
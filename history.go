@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// historyFileName es el archivo de historial dentro del home del usuario
+const historyFileName = ".neri_history"
+
+// defaultHistoryEntries es la cantidad mostrada por `history` sin argumento
+const defaultHistoryEntries = 10
+
+// Entrada del historial: una petición y el comando generado
+type HistoryEntry struct {
+	Time    time.Time `json:"time"`
+	Prompt  string    `json:"prompt"`
+	Command string    `json:"command"`
+}
+
+// History guarda las entradas de la sesión y las persiste como JSON lines
+type History struct {
+	path    string // vacío si no se pudo resolver el home: solo en memoria
+	entries []HistoryEntry
+}
+
+// NewHistory crea el historial en ~/.neri_history
+func NewHistory() *History {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return &History{}
+	}
+	return &History{path: filepath.Join(home, historyFileName)}
+}
+
+// Load lee las entradas guardadas; un archivo inexistente es un historial vacío
+func (h *History) Load() error {
+	if h.path == "" {
+		return nil
+	}
+	file, err := os.Open(h.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error leyendo historial: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		// Las líneas corruptas se ignoran para no perder el resto del historial
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+			h.entries = append(h.entries, entry)
+		}
+	}
+	return scanner.Err()
+}
+
+// Append agrega una entrada en memoria y la escribe al final del archivo
+func (h *History) Append(prompt, command string) error {
+	entry := HistoryEntry{Time: time.Now(), Prompt: prompt, Command: command}
+	h.entries = append(h.entries, entry)
+	if h.path == "" {
+		return nil
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("error guardando historial: %v", err)
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// Last devuelve las últimas n entradas, de la más antigua a la más reciente
+func (h *History) Last(n int) []HistoryEntry {
+	if n <= 0 || n > len(h.entries) {
+		n = len(h.entries)
+	}
+	return h.entries[len(h.entries)-n:]
+}
+
+// printHistory muestra las últimas entradas del historial
+func (ms *MiniShell) printHistory(arg string) {
+	n := defaultHistoryEntries
+	if arg != "" {
+		parsed, err := strconv.Atoi(arg)
+		if err != nil || parsed <= 0 {
+			fmt.Println("Uso: history [N]")
+			return
+		}
+		n = parsed
+	}
+
+	entries := ms.history.Last(n)
+	if len(entries) == 0 {
+		fmt.Println("Historial vacío")
+		return
+	}
+	for _, entry := range entries {
+		fmt.Printf("%s  %s\n    CMD: %s\n", entry.Time.Format("2006-01-02 15:04"), entry.Prompt, entry.Command)
+	}
+}
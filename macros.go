@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// maxMacroDepth limita la expansión anidada para evitar ciclos
+const maxMacroDepth = 5
+
+// macroRegex reconoce tokens @nombre al inicio o tras un espacio (no correos)
+var macroRegex = regexp.MustCompile(`(^|\s)@([A-Za-z][\w-]*)`)
+
+// loadMacros lee las macros de AI_MACROS_FILE (una por línea) y AI_MACROS (separadas por ';').
+// Las definiciones de AI_MACROS tienen prioridad sobre las del archivo.
+func loadMacros() map[string]string {
+	macros := make(map[string]string)
+
+	if path := os.Getenv("AI_MACROS_FILE"); path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			parseMacroDefinitions(macros, strings.Split(string(data), "\n"))
+		}
+	}
+	if inline := os.Getenv("AI_MACROS"); inline != "" {
+		parseMacroDefinitions(macros, strings.Split(inline, ";"))
+	}
+	return macros
+}
+
+// parseMacroDefinitions agrega definiciones "nombre=texto" al mapa, ignorando comentarios
+func parseMacroDefinitions(macros map[string]string, entries []string) {
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		name, definition, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		name = strings.TrimPrefix(strings.TrimSpace(name), "@")
+		macros[name] = strings.TrimSpace(definition)
+	}
+}
+
+// expandMacros reemplaza los tokens @nombre del input por sus definiciones
+func expandMacros(input string) string {
+	return expandMacrosWith(input, loadMacros())
+}
+
+// expandMacrosWith expande macros anidadas; las desconocidas se dejan intactas
+func expandMacrosWith(input string, macros map[string]string) string {
+	if len(macros) == 0 {
+		return input
+	}
+
+	for depth := 0; depth < maxMacroDepth; depth++ {
+		expanded := macroRegex.ReplaceAllStringFunc(input, func(match string) string {
+			groups := macroRegex.FindStringSubmatch(match)
+			if definition, ok := macros[groups[2]]; ok {
+				return groups[1] + definition
+			}
+			return match
+		})
+		if expanded == input {
+			break
+		}
+		input = expanded
+	}
+	return input
+}
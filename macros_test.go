@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestExpandMacrosWith(t *testing.T) {
+	macros := map[string]string{
+		"logs":   "los archivos .log de @varlog",
+		"varlog": "/var/log",
+		"ciclo":  "repetir @ciclo",
+	}
+	tests := []struct {
+		input, want string
+	}{
+		{"borra @logs", "borra los archivos .log de /var/log"},
+		{"@varlog ordenado", "/var/log ordenado"},
+		{"lista @desconocida", "lista @desconocida"},
+		{"escribe a yo@varlog.com", "escribe a yo@varlog.com"},
+		{"sin macros", "sin macros"},
+		{"@ciclo", "repetir repetir repetir repetir repetir @ciclo"},
+	}
+	for _, tt := range tests {
+		if got := expandMacrosWith(tt.input, macros); got != tt.want {
+			t.Errorf("expandMacrosWith(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseMacroDefinitions(t *testing.T) {
+	macros := make(map[string]string)
+	parseMacroDefinitions(macros, []string{"# comentario", "@logs = /var/log ", "sin-igual", ""})
+	if len(macros) != 1 || macros["logs"] != "/var/log" {
+		t.Errorf("parseMacroDefinitions() = %v, want map[logs:/var/log]", macros)
+	}
+}
@@ -2,21 +2,48 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
+
+	"github.com/EmilianoMAl/AI-Wrapper/aiwrapper"
 )
 
 // MiniShell representa el shell asistido por IA
 type MiniShell struct {
-	running bool
+	running       bool
+	manageSignals bool // false cuando el programa anfitrión maneja las señales
+	autoExec      bool // ejecutar sin confirmación si el comando pasa la verificación de seguridad
+	script        ScriptBuffer
+	history       *History
+	conversation  *aiwrapper.Conversation
+	stream        bool   // mostrar la respuesta de la IA a medida que llega
+	lastAccepted  string // último comando que el usuario aceptó ejecutar
+
+	childMu         sync.Mutex
+	child           *os.Process        // comando en ejecución, interrumpido por Ctrl+C
+	cancelRequest   context.CancelFunc // petición a la IA en curso, cancelada por Ctrl+C
+	requestCanceled bool
+	spinner         *Spinner // animación mientras se espera a la IA
 }
 
 // NewMiniShell crea una nueva instancia del shell
 func NewMiniShell() *MiniShell {
-	return &MiniShell{running: true}
+	return &MiniShell{
+		running:       true,
+		manageSignals: aiwrapper.GetEnvBool("AI_MANAGE_SIGNALS", true),
+		autoExec:      aiwrapper.GetEnvBool("AI_AUTO_EXEC", false),
+		history:       NewHistory(),
+		conversation:  aiwrapper.NewConversation(),
+	}
 }
 
 // setupSignalHandlers configura los manejadores de señales Unix
@@ -28,6 +55,14 @@ func (ms *MiniShell) setupSignalHandlers() {
 		for sig := range sigChan {
 			switch sig {
 			case syscall.SIGINT:
+				// Interrumpir el comando en ejecución sin cerrar el shell
+				if ms.interruptChild() {
+					continue
+				}
+				if ms.interruptRequest() {
+					fmt.Println("^C")
+					continue
+				}
 				fmt.Println("^C (usa 'exit' para salir)")
 				// No salir, solo volver al prompt
 			case syscall.SIGTERM:
@@ -41,7 +76,7 @@ func (ms *MiniShell) setupSignalHandlers() {
 
 // displayPrompt muestra el prompt del shell
 func (ms *MiniShell) displayPrompt() string {
-	return "neri> "
+	return colorize(colorCyan, "neri> ")
 }
 
 // shouldExit verifica si el usuario quiere salir
@@ -50,18 +85,148 @@ func (ms *MiniShell) shouldExit(input string) bool {
 	return input == "exit" || input == "quit"
 }
 
+// builtinArg devuelve el argumento si el input es el comando interno dado
+func builtinArg(input, name string) (string, bool) {
+	fields := strings.SplitN(strings.TrimSpace(input), " ", 2)
+	if !strings.EqualFold(fields[0], name) {
+		return "", false
+	}
+	if len(fields) == 1 {
+		return "", true
+	}
+	return strings.TrimSpace(fields[1]), true
+}
+
+// teach muestra el comando con una anotación por cada flag
+func (ms *MiniShell) teach(text string) {
+	if text == "" {
+		fmt.Println("Uso: teach <petición o comando>")
+		return
+	}
+
+	ctx, done := ms.requestContext()
+	defer done()
+	command, annotations, err := aiwrapper.TeachCommand(ctx, text)
+	if err != nil {
+		printError("Error procesando comando: %v", err)
+		return
+	}
+
+	fmt.Printf("CMD: %s\n", command)
+	for _, annotation := range annotations {
+		fmt.Printf("  %-12s %s\n", annotation.Flag, annotation.Meaning)
+	}
+}
+
+// explain muestra el comando y, por separado, una explicación de lo que hace
+func (ms *MiniShell) explain(text string) {
+	if text == "" {
+		fmt.Println("Uso: explain <petición>")
+		return
+	}
+
+	ctx, done := ms.requestContext()
+	defer done()
+	result, err := aiwrapper.ExplainCommand(ctx, text)
+	if err != nil {
+		printError("Error procesando comando: %v", err)
+		return
+	}
+
+	fmt.Printf("CMD: %s\n", result.Command)
+	if result.Explanation != "" {
+		fmt.Println(result.Explanation)
+	}
+}
+
+// fix pide la corrección de un comando, con el error opcional que produjo
+func (ms *MiniShell) fix(reader *bufio.Reader, command string) {
+	if command == "" {
+		fmt.Println("Uso: fix <comando>")
+		return
+	}
+
+	fmt.Print("Error (opcional, Enter para omitir): ")
+	errorOutput, _ := reader.ReadString('\n')
+
+	ctx, done := ms.requestContext()
+	defer done()
+	_, fixedCommand, err := aiwrapper.FixCommand(ctx, command, strings.TrimSpace(errorOutput))
+	if err != nil {
+		printError("Error procesando comando: %v", err)
+		return
+	}
+	fmt.Printf("CMD: %s\n", fixedCommand)
+}
+
+// dumpRequest muestra la petición que se enviaría a la IA sin enviarla
+func (ms *MiniShell) dumpRequest(text string) {
+	if text == "" {
+		fmt.Println("Uso: dump-request <prompt>")
+		return
+	}
+
+	dump, err := aiwrapper.DumpRequest(expandMacros(text), ms.conversation.Turns())
+	if err != nil {
+		printError("Error armando la petición: %v", err)
+		return
+	}
+	fmt.Println(dump)
+}
+
+// printPrivacy muestra cuántas peticiones de red se hicieron en la sesión
+func (ms *MiniShell) printPrivacy() {
+	config := aiwrapper.GetAIConfig()
+	fmt.Println("Al iniciar no se hace ninguna llamada de red: solo se leen variables de entorno.")
+	fmt.Println("Solo se contacta a la IA cuando escribes un prompt.")
+	fmt.Printf("Peticiones enviadas en esta sesión: %d (proveedor: %s)\n", aiwrapper.RequestCount(), config.Provider)
+	for _, host := range aiwrapper.RequestsByHost() {
+		fmt.Printf("  %s: %d\n", host.Host, host.Requests)
+	}
+}
+
+// printConfig muestra la configuración efectiva sin revelar la API key
+func (ms *MiniShell) printConfig() {
+	config := aiwrapper.GetAIConfig()
+
+	timeout := config.Timeout.String()
+	if config.Timeout == 0 {
+		timeout = "sin timeout"
+	}
+	apiKey := "no definida"
+	switch {
+	case config.AuthStyle == aiwrapper.AuthNone:
+		apiKey = "no requerida"
+	case config.APIKey != "":
+		apiKey = "definida"
+	case config.AuthStyle == aiwrapper.AuthOptional:
+		apiKey = "opcional"
+	}
+
+	fmt.Printf("Proveedor:     %s\n", config.Provider)
+	fmt.Printf("URL base:      %s\n", aiwrapper.Redact(config.BaseURL))
+	fmt.Printf("Modelo:        %s\n", config.Model)
+	fmt.Printf("Timeout:       %s\n", timeout)
+	fmt.Printf("Max tokens:    %d\n", config.MaxTokens)
+	fmt.Printf("Temperatura:   %g\n", config.Temperature)
+	fmt.Printf("API key:       %s\n", apiKey)
+	if fallback, ok := aiwrapper.GetFallbackConfig(); ok {
+		fmt.Printf("Respaldo:      %s (%s)\n", fallback.Provider, fallback.Model)
+	}
+	if path := aiwrapper.ConfigFilePath(); path != "" {
+		fmt.Printf("config.json:   %s\n", path)
+	}
+}
+
 // checkAPIKey verifica si existe la API key y muestra advertencia si no
 func (ms *MiniShell) checkAPIKey() {
-	provider := os.Getenv("AI_PROVIDER")
-	if provider == "" {
-		provider = "ollama"
-	}
+	config := aiwrapper.GetAIConfig()
+	provider := config.Provider
 
 	// Solo verificar API key para providers que la necesitan
-	if provider == "openai" || provider == "gemini" {
-		apiKey := os.Getenv("AI_API_KEY")
-		if apiKey == "" {
-			fmt.Println("⚠️  ADVERTENCIA: No se encontró AI_API_KEY en las variables de entorno")
+	if config.AuthStyle != "" && config.AuthStyle != aiwrapper.AuthNone && config.AuthStyle != aiwrapper.AuthOptional {
+		if config.APIKey == "" {
+			printWarning("⚠️  ADVERTENCIA: No se encontró AI_API_KEY en las variables de entorno")
 			fmt.Printf("   Para usar %s, configura: export AI_API_KEY=tu_clave\n", provider)
 			fmt.Println("   El programa continuará pero las llamadas a la API fallarán.")
 			fmt.Println()
@@ -69,16 +234,271 @@ func (ms *MiniShell) checkAPIKey() {
 	}
 }
 
+// checkModel advierte si el modelo configurado no es uno conocido del proveedor, sin bloquear
+func (ms *MiniShell) checkModel() {
+	config := aiwrapper.GetAIConfig()
+	if warning := aiwrapper.CheckModel(config.Provider, config.Model); warning != "" {
+		printWarning("⚠️  %s", warning)
+	}
+}
+
+// printModels muestra el modelo por defecto y los conocidos del proveedor actual
+func (ms *MiniShell) printModels() {
+	config := aiwrapper.GetAIConfig()
+	fmt.Printf("Proveedor:     %s\n", config.Provider)
+	if model := aiwrapper.DefaultModel(config.Provider); model != "" {
+		fmt.Printf("Por defecto:   %s\n", model)
+	}
+	models := aiwrapper.KnownModels(config.Provider)
+	if len(models) == 0 {
+		fmt.Println("Conocidos:     (sin lista para este proveedor)")
+		return
+	}
+	fmt.Println("Conocidos:")
+	for _, model := range models {
+		marker := " "
+		if model == config.Model {
+			marker = "*"
+		}
+		fmt.Printf("  %s %s\n", marker, model)
+	}
+}
+
+// promptPlaceholders pide al usuario un valor para cada marcador del comando
+func (ms *MiniShell) promptPlaceholders(reader *bufio.Reader, command string) string {
+	names := aiwrapper.ExtractPlaceholders(command)
+	if len(names) == 0 {
+		return command
+	}
+
+	fmt.Printf("Plantilla: %s\n", command)
+	values := make(map[string]string)
+	for _, name := range names {
+		fmt.Printf("  <%s>: ", name)
+		value, err := reader.ReadString('\n')
+		if err != nil {
+			break
+		}
+		values[name] = strings.TrimSpace(value)
+	}
+	return aiwrapper.FillPlaceholders(command, values)
+}
+
+// openCommandOutput abre el destino configurado para los comandos generados.
+// Devuelve nil si no hay AI_OUTPUT_FD ni AI_OUTPUT_PIPE.
+func openCommandOutput() (io.WriteCloser, error) {
+	if path := os.Getenv("AI_OUTPUT_PIPE"); path != "" {
+		// Abrir un FIFO bloquea hasta que haya un lector conectado
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			return nil, err
+		}
+		return file, nil
+	}
+	if value := os.Getenv("AI_OUTPUT_FD"); value != "" {
+		fd, err := strconv.Atoi(value)
+		if err != nil || fd < 0 {
+			return nil, fmt.Errorf("AI_OUTPUT_FD inválido: %q", value)
+		}
+		return os.NewFile(uintptr(fd), "AI_OUTPUT_FD"), nil
+	}
+	return nil, nil
+}
+
+// translate traduce el prompt con el contexto de la conversación
+func (ms *MiniShell) translate(prompt string) (*aiwrapper.TranslationResult, error) {
+	history := ms.conversation.Turns()
+	if len(history) > 0 && ms.lastAccepted != "" && aiwrapper.GetEnvBool("AI_INCLUDE_HELP", false) {
+		prompt = aiwrapper.WithHelpContext(prompt, ms.lastAccepted)
+	}
+	return ms.translateStream(prompt, history)
+}
+
+// translateStream traduce mostrando la respuesta de la IA mientras llega si el streaming está activo
+func (ms *MiniShell) translateStream(prompt string, history []aiwrapper.ChatTurn) (*aiwrapper.TranslationResult, error) {
+	ctx, done := ms.requestContext()
+	defer done()
+	if !ms.stream {
+		return aiwrapper.TranslateWithContext(ctx, prompt, history)
+	}
+	// La respuesta se muestra mientras llega, así que no hace falta el spinner
+	ms.stopSpinner()
+	fmt.Print("IA: ")
+	result, err := aiwrapper.TranslateStream(ctx, prompt, history, os.Stdout)
+	fmt.Println()
+	return result, err
+}
+
+// printResolvedPaths muestra las rutas relativas del comando en su forma absoluta
+func (ms *MiniShell) printResolvedPaths(command string) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return
+	}
+
+	paths := aiwrapper.ResolveCommandPaths(command, cwd)
+	args := make([]string, 0, len(paths))
+	for arg, resolved := range paths {
+		if arg != resolved {
+			args = append(args, arg)
+		}
+	}
+	sort.Strings(args)
+	for _, arg := range args {
+		fmt.Printf("  %s → %s\n", arg, paths[arg])
+	}
+}
+
+// Opciones del menú de recuperación
+const (
+	recoveryRetry    = "r"
+	recoveryEdit     = "e"
+	recoveryProvider = "p"
+	recoveryCancel   = "c"
+)
+
+// parseRecoveryChoice interpreta la respuesta al menú de recuperación. Una línea
+// vacía cancela; devuelve "" si la opción no existe y hay que volver a preguntar.
+func parseRecoveryChoice(input string) string {
+	switch choice := strings.ToLower(strings.TrimSpace(input)); choice {
+	case recoveryRetry, recoveryEdit, recoveryProvider, recoveryCancel:
+		return choice
+	case "":
+		return recoveryCancel
+	}
+	return ""
+}
+
+// recoveryMenu ofrece opciones tras una traducción fallida.
+// Devuelve el prompt a reintentar y false si el usuario cancela.
+func (ms *MiniShell) recoveryMenu(reader *bufio.Reader, prompt string) (string, bool) {
+	for {
+		fmt.Print("[r]eintentar, [e]ditar prompt, cambiar [p]roveedor, [c]ancelar: ")
+		choice, err := reader.ReadString('\n')
+		if err != nil {
+			return "", false
+		}
+
+		switch parseRecoveryChoice(choice) {
+		case recoveryRetry:
+			return prompt, true
+		case recoveryEdit:
+			fmt.Printf("Prompt actual: %s\nNuevo prompt: ", prompt)
+			edited, err := reader.ReadString('\n')
+			if err != nil {
+				return "", false
+			}
+			if edited = strings.TrimSpace(edited); edited != "" {
+				prompt = edited
+			}
+			return prompt, true
+		case recoveryProvider:
+			fmt.Printf("Proveedor actual: %s\nNuevo proveedor (%s): ", aiwrapper.GetAIConfig().Provider, strings.Join(aiwrapper.ProviderNames(), ", "))
+			provider, err := reader.ReadString('\n')
+			if err != nil {
+				return "", false
+			}
+			if provider = strings.TrimSpace(provider); provider != "" {
+				os.Setenv("AI_PROVIDER", provider)
+			}
+			return prompt, true
+		case recoveryCancel:
+			return "", false
+		}
+	}
+}
+
+// offerSudo advierte que el comando requiere root y ofrece anteponer sudo
+func (ms *MiniShell) offerSudo(reader *bufio.Reader, command string) string {
+	printWarning("⚠️  Este comando probablemente requiere privilegios de root")
+	if !isTerminal(os.Stdin) {
+		return command
+	}
+
+	fmt.Print("¿Anteponer sudo? [y/N]: ")
+	answer, err := reader.ReadString('\n')
+	if err != nil || strings.ToLower(strings.TrimSpace(answer)) != "y" {
+		return command
+	}
+	return "sudo " + command
+}
+
+// verify muestra el veredicto de la segunda llamada de verificación y devuelve si el comando la pasó
+func (ms *MiniShell) verify(userInput, command string) bool {
+	ctx, done := ms.requestContext()
+	defer done()
+	result, err := aiwrapper.VerifyCommand(ctx, userInput, command)
+	if err != nil {
+		printWarning("⚠️  %v", err)
+		return false
+	}
+
+	switch {
+	case !result.Known:
+		printWarning("⚠️  Verificación sin veredicto claro: %s", result.Reason)
+	case result.Matches:
+		fmt.Printf("✓ Verificación: cumple la petición. %s\n", result.Reason)
+	default:
+		fmt.Println(colorize(colorYellow, "⚠️  Verificación: el comando podría NO cumplir la petición. "+result.Reason))
+	}
+	return result.Known && result.Matches
+}
+
+// formatSummary construye la línea resumen de una traducción
+func (ms *MiniShell) formatSummary(config aiwrapper.AIConfig, latency time.Duration, tokens int, cached, dangerous bool) string {
+	summary := fmt.Sprintf("[%s · %s · %s · ~%d tokens",
+		config.Provider, config.Model, latency.Round(time.Millisecond), tokens)
+	if cost, ok := aiwrapper.EstimateCost(config.Provider, tokens); ok {
+		summary += fmt.Sprintf(" · $%.4f", cost)
+	}
+	if cached {
+		summary += " · caché"
+	}
+	if dangerous {
+		summary += " · peligroso"
+	}
+	return summary + "]"
+}
+
 // run ejecuta el loop principal REPL
 func (ms *MiniShell) run() {
-	fmt.Println("Mini-shell asistido por IA")
-	fmt.Println("Escribe 'exit' o 'quit' para salir")
-	fmt.Println()
+	if banner := getBanner(); banner != "" {
+		fmt.Println(banner)
+		fmt.Println()
+	}
+	printFirstRunTips()
 
 	// Verificar configuración de API
 	ms.checkAPIKey()
+	if aiwrapper.GetEnvBool("AI_DEBUG", false) {
+		ms.checkModel()
+	}
+
+	if err := ms.history.Load(); err != nil {
+		printWarning("⚠️  %v", err)
+	}
 
-	ms.setupSignalHandlers()
+	if ms.manageSignals {
+		ms.setupSignalHandlers()
+	}
+
+	// Destino alternativo para los comandos (editores, tmux)
+	commandOut, err := openCommandOutput()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error abriendo salida de comandos: %v\n", err)
+	}
+	if commandOut != nil {
+		defer commandOut.Close()
+	}
+
+	// Validar la plantilla de salida antes de empezar
+	outputTemplate, err := loadOutputTemplate()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "AI_OUTPUT_TEMPLATE inválida, se usa el formato por defecto: %v\n", err)
+		outputTemplate = nil
+	}
+	// Con plantilla la salida tiene un formato fijo, así que no se transmite la respuesta
+	ms.stream = aiwrapper.GetEnvBool("AI_STREAM", false) && outputTemplate == nil
 
 	reader := bufio.NewReader(os.Stdin)
 
@@ -88,8 +508,13 @@ func (ms *MiniShell) run() {
 		fmt.Print(prompt)
 
 		userInput, err := reader.ReadString('\n')
+		if err == io.EOF {
+			// Ctrl+D o fin de la entrada: salir del loop
+			fmt.Println()
+			break
+		}
 		if err != nil {
-			fmt.Printf("Error leyendo input: %v\n", err)
+			printError("Error leyendo input: %v", err)
 			continue
 		}
 
@@ -106,26 +531,290 @@ func (ms *MiniShell) run() {
 			break
 		}
 
+		// Comandos internos
+		if strings.EqualFold(userInput, "reset") {
+			aiwrapper.Cache.ClearMemory()
+			fmt.Println("Caché de sesión vaciada")
+			fmt.Println()
+			continue
+		}
+		if arg, ok := builtinArg(userInput, "cache"); ok {
+			if !strings.EqualFold(arg, "clear") {
+				fmt.Println("Uso: cache clear")
+			} else if err := aiwrapper.Cache.Clear(); err != nil {
+				printError("Error vaciando caché: %v", err)
+			} else {
+				fmt.Println("Caché vaciada (memoria y disco)")
+			}
+			fmt.Println()
+			continue
+		}
+		if strings.EqualFold(userInput, "clear") {
+			ms.conversation.Clear()
+			fmt.Println("Contexto de conversación vaciado")
+			fmt.Println()
+			continue
+		}
+		if strings.EqualFold(userInput, "tokens") {
+			ms.printTokens()
+			fmt.Println()
+			continue
+		}
+		if strings.EqualFold(userInput, "config") {
+			ms.printConfig()
+			fmt.Println()
+			continue
+		}
+		if strings.EqualFold(userInput, "models") {
+			ms.printModels()
+			fmt.Println()
+			continue
+		}
+		if strings.EqualFold(userInput, "privacy") {
+			ms.printPrivacy()
+			fmt.Println()
+			continue
+		}
+		if arg, ok := builtinArg(userInput, "teach"); ok {
+			ms.teach(arg)
+			fmt.Println()
+			continue
+		}
+		if arg, ok := builtinArg(userInput, "explain"); ok {
+			ms.explain(arg)
+			fmt.Println()
+			continue
+		}
+		if arg, ok := builtinArg(userInput, "dump-request"); ok {
+			ms.dumpRequest(arg)
+			fmt.Println()
+			continue
+		}
+		if arg, ok := builtinArg(userInput, "script"); ok {
+			ms.handleScript(reader, arg)
+			fmt.Println()
+			continue
+		}
+		if arg, ok := builtinArg(userInput, "history"); ok {
+			ms.printHistory(arg)
+			fmt.Println()
+			continue
+		}
+		if arg, ok := builtinArg(userInput, "fix"); ok {
+			ms.fix(reader, arg)
+			fmt.Println()
+			continue
+		}
+
+		typedInput := userInput
+
+		// Expandir macros @nombre antes de enviar al modelo
+		userInput = expandMacros(userInput)
+
+		// Sustituir $(comando) por su salida, con confirmación
+		substituted, err := substituteCommandOutput(userInput, func(command string) (string, error) {
+			return ms.runSubstitution(reader, command)
+		})
+		if err != nil {
+			printError("Error en sustitución: %v", err)
+			fmt.Println()
+			continue
+		}
+		userInput = substituted
+
+		// Dividir prompts demasiado largos y resumir cada parte
+		if aiwrapper.ShouldChunkPrompt(userInput) {
+			ctx, done := ms.requestContext()
+			combined, err := aiwrapper.ChunkAndCombine(ctx, userInput)
+			done()
+			if err != nil {
+				printError("Error procesando comando: %v", err)
+				fmt.Println()
+				continue
+			}
+			fmt.Printf("Prompt largo resumido en %d partes\n", len(aiwrapper.SplitIntoChunks(userInput, aiwrapper.GetChunkSize())))
+			userInput = combined
+		}
+
+		if aiwrapper.GetEnvBool("AI_WORKFLOW_MODE", false) {
+			ms.runWorkflow(reader, userInput)
+			fmt.Println()
+			continue
+		}
+
 		// Procesar comando a través de IA
-		rawResponse, finalCommand, err := TranslateToCommand(userInput)
+		start := time.Now()
+		result, err := ms.translate(userInput)
+		for err != nil {
+			if ms.wasCanceled() {
+				fmt.Println("Petición cancelada")
+				break
+			}
+			printError("Error procesando comando: %v", err)
+			if !isTerminal(os.Stdin) {
+				break
+			}
+
+			// Ofrecer recuperación guiada en lugar de solo mostrar el error
+			retryInput, retry := ms.recoveryMenu(reader, userInput)
+			if !retry {
+				break
+			}
+			userInput = retryInput
+			start = time.Now()
+			result, err = ms.translate(userInput)
+		}
+		latency := time.Since(start)
 		if err != nil {
-			fmt.Printf("Error procesando comando: %v\n", err)
 			fmt.Println()
 			continue
 		}
 
-		// Mostrar resultados (sin ejecutar)
-		if rawResponse != "" {
+		rawResponse, finalCommand, cached := result.Raw, result.Command, result.Cached
+
+		// Proveedor que respondió: el de respaldo si el principal falló
+		answered := aiwrapper.GetAIConfig()
+		if result.Provider != answered.Provider {
+			printWarning("⚠️  Respondió el proveedor de respaldo: %s", result.Provider)
+			answered.Provider, answered.Model = result.Provider, result.Model
+		}
+
+		// Mostrar resultados
+		if rawResponse != "" && outputTemplate == nil && (!ms.stream || cached) {
 			fmt.Printf("IA raw: %s\n", rawResponse)
 		}
-		fmt.Printf("CMD: %s\n", finalCommand)
+		if aiwrapper.GetEnvBool("AI_TEMPLATE_MODE", false) {
+			finalCommand = ms.promptPlaceholders(reader, finalCommand)
+		}
+		if aiwrapper.GetEnvBool("AI_EDIT", false) {
+			// Sin modo raw (stdin no es terminal o no hay stty) se sigue con el comando tal cual
+			if edited, ok := editLine(reader, "Editar: ", finalCommand); ok {
+				finalCommand = edited
+			}
+			if finalCommand == "" {
+				fmt.Println("Comando descartado")
+				fmt.Println()
+				continue
+			}
+		}
+		dangerous, reason := aiwrapper.IsDangerous(finalCommand)
+		if dangerous && !ms.confirmDangerous(reader, finalCommand, reason) {
+			fmt.Println("Comando descartado")
+			fmt.Println()
+			continue
+		}
+		if aiwrapper.GetEnvBool("AI_BLOCK_CHAINED", false) && aiwrapper.HasChainedCommands(finalCommand) {
+			fmt.Println(colorize(colorRed, "⚠️  Comando rechazado: encadena varios comandos (;, &, &&, ||, saltos de línea, $(...) o backticks)"))
+			fmt.Printf("CMD: %s\n", finalCommand)
+			fmt.Println()
+			continue
+		}
+		if !aiwrapper.IsRoot() && aiwrapper.NeedsRoot(finalCommand) {
+			finalCommand = ms.offerSudo(reader, finalCommand)
+		}
+		if commandOut != nil {
+			fmt.Fprintln(commandOut, finalCommand)
+		} else if outputTemplate != nil {
+			rendered, err := renderOutput(outputTemplate, OutputData{
+				Prompt:   userInput,
+				Command:  finalCommand,
+				Raw:      rawResponse,
+				Provider: answered.Provider,
+				Model:    answered.Model,
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error en AI_OUTPUT_TEMPLATE: %v\n", err)
+			}
+			fmt.Print(rendered)
+		} else if colorEnabled() {
+			fmt.Printf("%s %s\n", colorize(colorGreen, "CMD:"), highlightCommand(finalCommand))
+		} else {
+			fmt.Printf("CMD: %s\n", finalCommand)
+		}
+		ms.conversation.Add(userInput, finalCommand)
+		if err := ms.history.Append(typedInput, finalCommand); err != nil {
+			printWarning("⚠️  %v", err)
+		}
+		codeLanguage := aiwrapper.ParseCommandInfo(rawResponse).Language
+		if codeLanguage != "" {
+			printWarning("⚠️  La IA respondió con código %s, no con un comando de shell: no se ejecuta", codeLanguage)
+		}
+		if mismatch := aiwrapper.DomainMismatch(finalCommand, aiwrapper.GetDomain()); mismatch != "" {
+			printWarning("⚠️  %s", mismatch)
+		}
+		if aiwrapper.IsPOSIXDialect(aiwrapper.GetShellDialect()) {
+			if found := aiwrapper.DetectBashisms(finalCommand); len(found) > 0 {
+				printWarning("⚠️  El comando usa construcciones de bash no POSIX: %s", strings.Join(found, ", "))
+			}
+		}
+		ms.printResolvedPaths(finalCommand)
+		if warning := aiwrapper.CheckDiskSpace(finalCommand, "."); warning != "" {
+			fmt.Println(warning)
+		}
+		if aiwrapper.GetEnvBool("AI_SUMMARY", false) {
+			fmt.Println(ms.formatSummary(answered, latency, result.TokensUsed, cached, dangerous))
+		}
+
+		verifyFailed := false
+		if aiwrapper.GetEnvBool("AI_VERIFY", false) {
+			verifyFailed = !ms.verify(userInput, finalCommand)
+		}
+
+		// El código en otro lenguaje nunca se pasa al shell, ni siquiera confirmado,
+		// y tampoco entra al script
+		if codeLanguage != "" {
+			fmt.Println()
+			continue
+		}
+		if ms.script.recording {
+			ms.script.Append(finalCommand)
+		}
+
+		// Precedencia: con autoExec se ejecuta directamente si pasa la verificación
+		// de seguridad; si no la pasa, o sin autoExec, se pide confirmación
+		// (solo en modo interactivo, si no, el comando solo se muestra).
+		if ms.autoExec {
+			reason := autoExecBlockReason(finalCommand, rawResponse)
+			if reason == "" && verifyFailed {
+				reason = "la verificación no confirmó que el comando cumpla la petición"
+			}
+			if reason != "" {
+				printWarning("⚠️  No se ejecuta automáticamente: %s", reason)
+			} else {
+				ms.runCommand(finalCommand)
+				fmt.Println()
+				continue
+			}
+		}
+		if isTerminal(os.Stdin) && ms.confirmWithRisk(reader, finalCommand, dangerous) {
+			ms.runCommand(finalCommand)
+		}
 		fmt.Println()
 	}
 
+	if aiwrapper.GetEnvBool("AI_USAGE_SUMMARY", false) {
+		ms.printUsage()
+	}
 	fmt.Println("Hasta luego!")
 }
 
 func main() {
+	// --check revisa la configuración y sale
+	if len(os.Args) == 2 && os.Args[1] == "--check" {
+		shell := NewMiniShell()
+		shell.checkAPIKey()
+		shell.checkModel()
+		return
+	}
+	// Con un prompt como argumento se responde en JSON y se sale, sin REPL
+	if len(os.Args) > 1 {
+		os.Exit(runOneShot(os.Args[1:], os.Stdout))
+	}
+	// Con stdin redirigido, toda la entrada es un único prompt y solo se imprime el comando
+	if !isTerminal(os.Stdin) {
+		os.Exit(runPipe(os.Stdin, os.Stdout, os.Stderr))
+	}
+
 	shell := NewMiniShell()
 	shell.run()
 }
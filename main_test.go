@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EmilianoMAl/AI-Wrapper/aiwrapper"
+)
+
+func TestNewMiniShellManageSignals(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"", true},
+		{"true", true},
+		{"false", false},
+		{"0", false},
+	}
+	for _, tt := range tests {
+		t.Setenv("AI_MANAGE_SIGNALS", tt.value)
+		if got := NewMiniShell().manageSignals; got != tt.want {
+			t.Errorf("NewMiniShell() with AI_MANAGE_SIGNALS=%q: manageSignals = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFormatSummary(t *testing.T) {
+	config := aiwrapper.AIConfig{Provider: "openai", Model: "gpt-4o-mini"}
+	ms := &MiniShell{}
+
+	t.Setenv("AI_OPENAI_PRICE_PER_1K", "")
+	got := ms.formatSummary(config, 1234567*time.Microsecond, 120, false, false)
+	if want := "[openai · gpt-4o-mini · 1.235s · ~120 tokens]"; got != want {
+		t.Errorf("formatSummary() = %q, want %q", got, want)
+	}
+
+	t.Setenv("AI_OPENAI_PRICE_PER_1K", "0.5")
+	got = ms.formatSummary(config, 80*time.Millisecond, 2000, true, true)
+	if want := "[openai · gpt-4o-mini · 80ms · ~2000 tokens · $1.0000 · caché · peligroso]"; got != want {
+		t.Errorf("formatSummary() = %q, want %q", got, want)
+	}
+}
+
+func TestOpenCommandOutput(t *testing.T) {
+	t.Setenv("AI_OUTPUT_PIPE", "")
+	t.Setenv("AI_OUTPUT_FD", "")
+	out, err := openCommandOutput()
+	if out != nil || err != nil {
+		t.Errorf("openCommandOutput() without configuration = %v, %v, want nil, nil", out, err)
+	}
+
+	for _, value := range []string{"abc", "-1"} {
+		t.Setenv("AI_OUTPUT_FD", value)
+		if _, err := openCommandOutput(); err == nil {
+			t.Errorf("openCommandOutput() with AI_OUTPUT_FD=%q: expected error", value)
+		}
+	}
+
+	fdFile, err := os.Create(filepath.Join(t.TempDir(), "fd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fdFile.Close()
+	t.Setenv("AI_OUTPUT_FD", strconv.Itoa(int(fdFile.Fd())))
+	out, err = openCommandOutput()
+	if err != nil || out == nil {
+		t.Fatalf("openCommandOutput() with a valid AI_OUTPUT_FD = %v, %v", out, err)
+	}
+	if _, err := out.Write([]byte("ls\n")); err != nil {
+		t.Errorf("writing to AI_OUTPUT_FD: %v", err)
+	}
+	if data, _ := os.ReadFile(fdFile.Name()); string(data) != "ls\n" {
+		t.Errorf("AI_OUTPUT_FD contents = %q, want %q", data, "ls\n")
+	}
+
+	path := filepath.Join(t.TempDir(), "comandos")
+	if err := os.WriteFile(path, []byte("pwd\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AI_OUTPUT_FD", "abc")
+	t.Setenv("AI_OUTPUT_PIPE", path)
+	out, err = openCommandOutput()
+	if err != nil {
+		t.Fatalf("openCommandOutput() with AI_OUTPUT_PIPE error = %v", err)
+	}
+	if _, err := out.Write([]byte("ls -la\n")); err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+	if data, _ := os.ReadFile(path); string(data) != "pwd\nls -la\n" {
+		t.Errorf("AI_OUTPUT_PIPE contents = %q, want the command appended", data)
+	}
+
+	t.Setenv("AI_OUTPUT_PIPE", filepath.Join(t.TempDir(), "no-existe", "fifo"))
+	if _, err := openCommandOutput(); err == nil {
+		t.Error("openCommandOutput() with a missing AI_OUTPUT_PIPE: expected error")
+	}
+}
+
+func TestParseRecoveryChoice(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"r\n", recoveryRetry},
+		{" E ", recoveryEdit},
+		{"p", recoveryProvider},
+		{"c", recoveryCancel},
+		{"", recoveryCancel},
+		{"\n", recoveryCancel},
+		{"x", ""},
+		{"reintentar", ""},
+	}
+	for _, tt := range tests {
+		if got := parseRecoveryChoice(tt.input); got != tt.want {
+			t.Errorf("parseRecoveryChoice(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestRecoveryMenu(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("AI_PROVIDER", "openai")
+	tests := []struct {
+		input, want  string
+		wantRetry    bool
+		wantProvider string
+	}{
+		{"r\n", "listar archivos", true, "openai"},
+		{"x\nr\n", "listar archivos", true, "openai"},
+		{"e\nlistar ocultos\n", "listar ocultos", true, "openai"},
+		{"e\n\n", "listar archivos", true, "openai"},
+		{"p\nollama\n", "listar archivos", true, "ollama"},
+		{"c\n", "", false, "openai"},
+		{"", "", false, "openai"},
+	}
+	ms := &MiniShell{}
+	for _, tt := range tests {
+		t.Setenv("AI_PROVIDER", "openai")
+		got, retry := ms.recoveryMenu(bufio.NewReader(strings.NewReader(tt.input)), "listar archivos")
+		if got != tt.want || retry != tt.wantRetry {
+			t.Errorf("recoveryMenu(%q) = %q, %v, want %q, %v", tt.input, got, retry, tt.want, tt.wantRetry)
+		}
+		if provider := os.Getenv("AI_PROVIDER"); provider != tt.wantProvider {
+			t.Errorf("recoveryMenu(%q): AI_PROVIDER = %q, want %q", tt.input, provider, tt.wantProvider)
+		}
+	}
+}
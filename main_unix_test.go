@@ -0,0 +1,43 @@
+//go:build unix
+
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestOpenCommandOutputFIFO(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fifo")
+	if err := syscall.Mkfifo(path, 0600); err != nil {
+		t.Skipf("mkfifo: %v", err)
+	}
+	t.Setenv("AI_OUTPUT_PIPE", path)
+	t.Setenv("AI_OUTPUT_FD", "")
+
+	// Abrir el FIFO para escritura bloquea hasta que haya un lector
+	received := make(chan string)
+	go func() {
+		reader, err := os.Open(path)
+		if err != nil {
+			received <- ""
+			return
+		}
+		defer reader.Close()
+		data, _ := io.ReadAll(reader)
+		received <- string(data)
+	}()
+
+	out, err := openCommandOutput()
+	if err != nil {
+		t.Fatalf("openCommandOutput() error = %v", err)
+	}
+	out.Write([]byte("ls -la\n"))
+	out.Close()
+	if got := <-received; got != "ls -la\n" {
+		t.Errorf("FIFO received %q, want %q", got, "ls -la\n")
+	}
+}
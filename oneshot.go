@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/EmilianoMAl/AI-Wrapper/aiwrapper"
+)
+
+// Resultado del modo no interactivo, impreso como JSON
+type OneShotResult struct {
+	Prompt   string `json:"prompt"`
+	Raw      string `json:"raw,omitempty"`
+	Command  string `json:"command,omitempty"`
+	Provider string `json:"provider"`
+	Model    string `json:"model,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// runOneShot traduce el prompt de la línea de comandos, imprime el resultado
+// como JSON en out y devuelve el código de salida (1 si hubo error)
+func runOneShot(args []string, out io.Writer) int {
+	if len(args) > 0 && args[0] == "--json" {
+		args = args[1:]
+	}
+	prompt := strings.TrimSpace(strings.Join(args, " "))
+	config := aiwrapper.GetAIConfig()
+	result := OneShotResult{Prompt: prompt, Provider: config.Provider, Model: config.Model}
+
+	if prompt == "" {
+		result.Error = "uso: neri [--json] \"<petición>\""
+		return writeOneShot(out, result)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	translation, err := aiwrapper.TranslateToCommand(ctx, expandMacros(prompt))
+	if err != nil {
+		result.Error = err.Error()
+		return writeOneShot(out, result)
+	}
+	result.Raw, result.Command = translation.Raw, translation.Command
+	result.Provider, result.Model = translation.Provider, translation.Model
+	return writeOneShot(out, result)
+}
+
+// runPipe lee toda la entrada como un único prompt e imprime solo el comando,
+// para usar el programa en pipelines (echo "..." | neri)
+func runPipe(in io.Reader, out, errOut io.Writer) int {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		fmt.Fprintf(errOut, "Error leyendo stdin: %v\n", err)
+		return 1
+	}
+	prompt := strings.TrimSpace(string(data))
+	if prompt == "" {
+		fmt.Fprintln(errOut, "Error: stdin vacío, se esperaba una petición")
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	result, err := aiwrapper.TranslateToCommand(ctx, expandMacros(prompt))
+	if err != nil {
+		fmt.Fprintf(errOut, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintln(out, result.Command)
+	return 0
+}
+
+// writeOneShot imprime el resultado y devuelve 1 si contiene un error
+func writeOneShot(out io.Writer, result OneShotResult) int {
+	data, err := json.Marshal(result)
+	if err != nil {
+		fmt.Fprintf(out, "{\"error\": %q}\n", err.Error())
+		return 1
+	}
+	fmt.Fprintln(out, string(data))
+	if result.Error != "" {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"text/template"
+)
+
+// Datos disponibles en AI_OUTPUT_TEMPLATE
+type OutputData struct {
+	Prompt   string
+	Command  string
+	Raw      string
+	Provider string
+	Model    string
+}
+
+// templateEscapes interpreta \n y \t escritos literalmente en la variable de entorno
+var templateEscapes = strings.NewReplacer(`\n`, "\n", `\t`, "\t")
+
+// loadOutputTemplate parsea AI_OUTPUT_TEMPLATE; devuelve nil si no está definida
+func loadOutputTemplate() (*template.Template, error) {
+	text := os.Getenv("AI_OUTPUT_TEMPLATE")
+	if text == "" {
+		return nil, nil
+	}
+	return template.New("output").Option("missingkey=error").Parse(templateEscapes.Replace(text))
+}
+
+// renderOutput aplica la plantilla a los datos de una traducción
+func renderOutput(tmpl *template.Template, data OutputData) (string, error) {
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
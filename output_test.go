@@ -0,0 +1,47 @@
+package main
+
+import "testing"
+
+func TestRenderOutput(t *testing.T) {
+	data := OutputData{Prompt: "listar archivos", Command: "ls -la", Raw: "```bash\nls -la\n```", Provider: "ollama", Model: "llama3"}
+	tests := []struct {
+		template, want string
+	}{
+		{"{{.Command}}", "ls -la"},
+		{`{{.Provider}}/{{.Model}}: {{.Command}}\n`, "ollama/llama3: ls -la\n"},
+		{`# {{.Prompt}}\t{{.Command}}`, "# listar archivos\tls -la"},
+		{`{{printf "%q" .Command}}`, `"ls -la"`},
+	}
+	for _, tt := range tests {
+		t.Setenv("AI_OUTPUT_TEMPLATE", tt.template)
+		tmpl, err := loadOutputTemplate()
+		if err != nil {
+			t.Fatalf("loadOutputTemplate(%q) error = %v", tt.template, err)
+		}
+		got, err := renderOutput(tmpl, data)
+		if err != nil || got != tt.want {
+			t.Errorf("renderOutput(%q) = %q, %v, want %q", tt.template, got, err, tt.want)
+		}
+	}
+}
+
+func TestRenderOutputErrors(t *testing.T) {
+	t.Setenv("AI_OUTPUT_TEMPLATE", "{{.Comando}}")
+	tmpl, err := loadOutputTemplate()
+	if err != nil {
+		t.Fatalf("loadOutputTemplate() error = %v", err)
+	}
+	if _, err := renderOutput(tmpl, OutputData{}); err == nil {
+		t.Error("renderOutput({{.Comando}}) error = nil, want unknown field error")
+	}
+
+	t.Setenv("AI_OUTPUT_TEMPLATE", "{{.Command")
+	if _, err := loadOutputTemplate(); err == nil {
+		t.Error("loadOutputTemplate({{.Command) error = nil, want parse error")
+	}
+
+	t.Setenv("AI_OUTPUT_TEMPLATE", "")
+	if tmpl, err := loadOutputTemplate(); tmpl != nil || err != nil {
+		t.Errorf("loadOutputTemplate(\"\") = %v, %v, want nil, nil", tmpl, err)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/EmilianoMAl/AI-Wrapper/aiwrapper"
+)
+
+// ScriptBuffer acumula comandos aceptados para guardarlos como un script
+type ScriptBuffer struct {
+	commands  []string
+	recording bool
+}
+
+// Append agrega un comando al final del script
+func (sb *ScriptBuffer) Append(command string) {
+	sb.commands = append(sb.commands, command)
+}
+
+// Pop quita y devuelve el último comando del script
+func (sb *ScriptBuffer) Pop() (string, bool) {
+	if len(sb.commands) == 0 {
+		return "", false
+	}
+	last := sb.commands[len(sb.commands)-1]
+	sb.commands = sb.commands[:len(sb.commands)-1]
+	return last, true
+}
+
+// Clear vacía el script
+func (sb *ScriptBuffer) Clear() {
+	sb.commands = nil
+}
+
+// Content devuelve el script completo con shebang
+func (sb *ScriptBuffer) Content() string {
+	var content strings.Builder
+	content.WriteString("#!/bin/sh\n")
+	for _, command := range sb.commands {
+		content.WriteString(command)
+		content.WriteString("\n")
+	}
+	return content.String()
+}
+
+// invalidEntry devuelve la posición (desde 1) de la primera entrada que no es
+// un comando de shell de una línea, o 0 si todas lo son. Los comandos generados
+// siempre son de una línea; un bloque de varias es código de otro lenguaje.
+func (sb *ScriptBuffer) invalidEntry() int {
+	for i, command := range sb.commands {
+		if strings.TrimSpace(command) == "" || strings.ContainsAny(command, "\r\n") {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Save escribe el script en path con permisos de ejecución
+func (sb *ScriptBuffer) Save(path string) error {
+	return os.WriteFile(path, []byte(sb.Content()), 0755)
+}
+
+// handleScript procesa los subcomandos de `script`
+func (ms *MiniShell) handleScript(reader *bufio.Reader, arg string) {
+	subcommand, rest, _ := strings.Cut(arg, " ")
+	rest = strings.TrimSpace(rest)
+
+	switch strings.ToLower(subcommand) {
+	case "", "on":
+		ms.script.recording = true
+		fmt.Println("Modo script activado: los comandos generados se agregan al script")
+	case "off":
+		ms.script.recording = false
+		fmt.Println("Modo script desactivado")
+	case "show":
+		if len(ms.script.commands) == 0 {
+			fmt.Println("(script vacío)")
+			return
+		}
+		for i, command := range ms.script.commands {
+			fmt.Printf("%3d  %s\n", i+1, command)
+		}
+	case "pop":
+		if command, ok := ms.script.Pop(); ok {
+			fmt.Printf("Quitado: %s\n", command)
+		} else {
+			fmt.Println("(script vacío)")
+		}
+	case "clear":
+		ms.script.Clear()
+		fmt.Println("Script vaciado")
+	case "save":
+		if rest == "" {
+			fmt.Println("Uso: script save <ruta>")
+			return
+		}
+		if err := ms.script.Save(rest); err != nil {
+			printError("Error guardando script: %v", err)
+			return
+		}
+		fmt.Printf("Script guardado en %s (%d comandos)\n", rest, len(ms.script.commands))
+	case "run":
+		ms.runScript(reader)
+	default:
+		fmt.Println("Uso: script [on|off|show|pop|clear|save <ruta>|run]")
+	}
+}
+
+// runScript muestra el script y lo ejecuta completo tras la misma confirmación
+// que un comando generado
+func (ms *MiniShell) runScript(reader *bufio.Reader) {
+	if len(ms.script.commands) == 0 {
+		fmt.Println("(script vacío)")
+		return
+	}
+	if entry := ms.script.invalidEntry(); entry > 0 {
+		printError("La entrada %d del script no es un comando de shell; quítala antes de ejecutar", entry)
+		return
+	}
+	script := strings.Join(ms.script.commands, "\n")
+	for i, command := range ms.script.commands {
+		fmt.Printf("%3d  %s\n", i+1, command)
+	}
+	if !isTerminal(os.Stdin) {
+		return
+	}
+
+	dangerous, reason := aiwrapper.IsDangerous(script)
+	if dangerous && !ms.confirmDangerous(reader, script, reason) {
+		fmt.Println("Script cancelado")
+		return
+	}
+	if ms.confirmWithRisk(reader, script, dangerous) {
+		ms.runCommand(script)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScriptBuffer(t *testing.T) {
+	var sb ScriptBuffer
+	if got, want := sb.Content(), "#!/bin/sh\n"; got != want {
+		t.Errorf("Content() empty = %q, want %q", got, want)
+	}
+	if command, ok := sb.Pop(); ok || command != "" {
+		t.Errorf("Pop() empty = %q, %v, want \"\", false", command, ok)
+	}
+
+	sb.Append("mkdir -p build")
+	sb.Append("make")
+	sb.Append("make install")
+	if command, ok := sb.Pop(); !ok || command != "make install" {
+		t.Errorf("Pop() = %q, %v, want \"make install\", true", command, ok)
+	}
+	if got, want := sb.Content(), "#!/bin/sh\nmkdir -p build\nmake\n"; got != want {
+		t.Errorf("Content() = %q, want %q", got, want)
+	}
+
+	sb.Clear()
+	if got, want := sb.Content(), "#!/bin/sh\n"; got != want {
+		t.Errorf("Content() after Clear = %q, want %q", got, want)
+	}
+}
+
+func TestScriptBufferSave(t *testing.T) {
+	var sb ScriptBuffer
+	sb.Append("df -h")
+	sb.Append("du -sh .")
+
+	path := filepath.Join(t.TempDir(), "limpieza.sh")
+	if err := sb.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "#!/bin/sh\ndf -h\ndu -sh .\n"; got != want {
+		t.Errorf("saved script = %q, want %q", got, want)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode&0100 == 0 {
+		t.Errorf("saved script mode = %v, want executable", mode)
+	}
+}
+
+func TestScriptBufferInvalidEntry(t *testing.T) {
+	tests := []struct {
+		commands []string
+		want     int
+	}{
+		{nil, 0},
+		{[]string{"ls -la", "df -h"}, 0},
+		{[]string{"ls -la", "import os\nprint(os.listdir())"}, 2},
+		{[]string{"  ", "ls"}, 1},
+	}
+	for _, tt := range tests {
+		sb := ScriptBuffer{commands: tt.commands}
+		if got := sb.invalidEntry(); got != tt.want {
+			t.Errorf("invalidEntry(%q) = %d, want %d", tt.commands, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/EmilianoMAl/AI-Wrapper/aiwrapper"
+)
+
+// spinnerFrames son los cuadros de la animación mientras se espera a la IA
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinnerInterval es el tiempo entre cuadros
+const spinnerInterval = 100 * time.Millisecond
+
+// Spinner animado que se dibuja en la línea actual hasta que se detiene
+type Spinner struct {
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// spinnerEnabled indica si se muestra el spinner: solo en terminales y si AI_SPINNER no lo desactiva
+func spinnerEnabled() bool {
+	return aiwrapper.GetEnvBool("AI_SPINNER", true) && isTerminal(os.Stdout)
+}
+
+// startSpinner muestra el spinner con el mensaje; devuelve nil si está desactivado
+func startSpinner(message string) *Spinner {
+	if !spinnerEnabled() {
+		return nil
+	}
+
+	s := &Spinner{stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(spinnerInterval)
+		defer ticker.Stop()
+		for frame := 0; ; frame++ {
+			fmt.Printf("\r%s %s", colorize(colorCyan, spinnerFrames[frame%len(spinnerFrames)]), message)
+			select {
+			case <-s.stop:
+				// Borrar la línea para que la respuesta empiece limpia
+				fmt.Print("\r\x1b[K")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return s
+}
+
+// Stop detiene el spinner y espera a que se borre la línea; es seguro llamarlo varias veces
+func (s *Spinner) Stop() {
+	if s == nil {
+		return
+	}
+	s.once.Do(func() { close(s.stop) })
+	<-s.done
+}
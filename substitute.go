@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/EmilianoMAl/AI-Wrapper/aiwrapper"
+)
+
+// substitutionTimeout limita cuánto puede tardar un comando sustituido
+const substitutionTimeout = 10 * time.Second
+
+// maxSubstitutionOutput limita los bytes de salida insertados en el prompt
+const maxSubstitutionOutput = 4000
+
+// findSubstitutions devuelve los rangos [inicio, fin) de cada $(...) balanceado del
+// input. Como en el shell, lo que está entre comillas simples cerradas no se sustituye.
+func findSubstitutions(input string) [][2]int {
+	var spans [][2]int
+	for i := 0; i+1 < len(input); i++ {
+		if input[i] == '\'' {
+			if end := strings.IndexByte(input[i+1:], '\''); end >= 0 {
+				i += end + 1
+			}
+			continue
+		}
+		if input[i] != '$' || input[i+1] != '(' {
+			continue
+		}
+		depth := 0
+		for j := i + 1; j < len(input); j++ {
+			switch input[j] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+			if depth == 0 {
+				spans = append(spans, [2]int{i, j + 1})
+				i = j
+				break
+			}
+		}
+	}
+	return spans
+}
+
+// substituteCommandOutput reemplaza cada $(comando) del input por la salida que
+// devuelve run. Los comandos que no son de solo lectura se rechazan sin llamar a run.
+func substituteCommandOutput(input string, run func(cmd string) (string, error)) (string, error) {
+	spans := findSubstitutions(input)
+	if len(spans) == 0 {
+		return input, nil
+	}
+
+	var out strings.Builder
+	last := 0
+	for _, span := range spans {
+		command := strings.TrimSpace(input[span[0]+2 : span[1]-1])
+		if !aiwrapper.IsReadOnlyCommand(command) {
+			return "", fmt.Errorf("$(%s): solo se permiten comandos de solo lectura (ls, cat, df, ps, git status, ...)", command)
+		}
+		output, err := run(command)
+		if err != nil {
+			return "", fmt.Errorf("$(%s): %v", command, err)
+		}
+		out.WriteString(input[last:span[0]])
+		out.WriteString(strings.TrimRight(output, "\n"))
+		last = span[1]
+	}
+	out.WriteString(input[last:])
+	return out.String(), nil
+}
+
+// runSubstitution confirma y ejecuta un comando ya validado como de solo lectura,
+// devolviendo su salida
+func (ms *MiniShell) runSubstitution(reader *bufio.Reader, command string) (string, error) {
+	if !isTerminal(os.Stdin) {
+		return "", fmt.Errorf("la sustitución requiere confirmación interactiva")
+	}
+
+	fmt.Printf("Ejecutar `%s` para completar el prompt? [y/N]: ", command)
+	answer, err := reader.ReadString('\n')
+	if err != nil || strings.ToLower(strings.TrimSpace(answer)) != "y" {
+		return "", fmt.Errorf("cancelado por el usuario")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), substitutionTimeout)
+	defer cancel()
+	shell, args := aiwrapper.ShellCommand(command)
+	output, err := exec.CommandContext(ctx, shell, args...).Output()
+	if err != nil {
+		return "", err
+	}
+	if len(output) > maxSubstitutionOutput {
+		output = append(output[:maxSubstitutionOutput], "\n[salida truncada]"...)
+	}
+	return string(output), nil
+}
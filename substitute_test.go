@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindSubstitutions(t *testing.T) {
+	tests := []struct {
+		input string
+		want  [][2]int
+	}{
+		{"sin sustituciones", nil},
+		{"resume $(cat notas.txt)", [][2]int{{7, 23}}},
+		{"$(pwd) y $(whoami)", [][2]int{{0, 6}, {9, 18}}},
+		{"$(echo $(pwd))", [][2]int{{0, 14}}},
+		{"literal '$(ls)' aquí", nil},
+		{"it's $(pwd)", [][2]int{{5, 11}}},
+		{"sin cerrar $(ls -la", nil},
+	}
+	for _, tt := range tests {
+		if got := findSubstitutions(tt.input); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("findSubstitutions(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestSubstituteCommandOutput(t *testing.T) {
+	outputs := map[string]string{"cat notas.txt": "comprar pan\n", "pwd": "/home/neri\n"}
+	tests := []struct {
+		input, want, wantErr string
+		wantRun              []string
+	}{
+		{input: "resume $(cat notas.txt)", want: "resume comprar pan", wantRun: []string{"cat notas.txt"}},
+		{input: "lista $( pwd ) completo", want: "lista /home/neri completo", wantRun: []string{"pwd"}},
+		{input: "borra $(rm -rf ~)", wantErr: "solo lectura"},
+		{input: "$(echo $(pwd))", wantErr: "solo lectura"},
+		{input: "literal '$(rm -rf ~)'", want: "literal '$(rm -rf ~)'"},
+		{input: "sin cerrar $(rm -rf ~", want: "sin cerrar $(rm -rf ~"},
+		{input: "falla $(cat faltante)", wantErr: "no existe", wantRun: []string{"cat faltante"}},
+	}
+	for _, tt := range tests {
+		var ran []string
+		got, err := substituteCommandOutput(tt.input, func(cmd string) (string, error) {
+			ran = append(ran, cmd)
+			if output, ok := outputs[cmd]; ok {
+				return output, nil
+			}
+			return "", errors.New("no existe")
+		})
+		switch {
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("substituteCommandOutput(%q) error = %v, want %q", tt.input, err, tt.wantErr)
+		case tt.wantErr == "" && (err != nil || got != tt.want):
+			t.Errorf("substituteCommandOutput(%q) = %q, %v, want %q", tt.input, got, err, tt.want)
+		}
+		if !reflect.DeepEqual(ran, tt.wantRun) {
+			t.Errorf("substituteCommandOutput(%q) ran %q, want %q", tt.input, ran, tt.wantRun)
+		}
+	}
+}
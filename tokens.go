@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/EmilianoMAl/AI-Wrapper/aiwrapper"
+)
+
+// printTokens muestra los tokens estimados frente a los reportados en la sesión
+func (ms *MiniShell) printTokens() {
+	stats := aiwrapper.GetTokenStats()
+	fmt.Printf("Peticiones:            %d\n", stats.Requests)
+	fmt.Printf("Tokens estimados:      %d\n", stats.Estimated)
+	if stats.ReportedRequests == 0 {
+		fmt.Println("Tokens reportados:     (el proveedor no reportó uso)")
+		return
+	}
+	fmt.Printf("Tokens reportados:     %d (en %d peticiones)\n", stats.Reported, stats.ReportedRequests)
+	fmt.Printf("Estimado equivalente:  %d\n", stats.EstimatedReported)
+	fmt.Printf("Diferencia:            %+d\n", stats.Delta())
+}
+
+// printUsage muestra los tokens de la sesión por proveedor con su costo estimado
+func (ms *MiniShell) printUsage() {
+	usages := aiwrapper.UsageByProvider()
+	if len(usages) == 0 {
+		fmt.Println("Sin peticiones a la IA en esta sesión")
+		return
+	}
+
+	totalTokens, totalCost, priced := 0, 0.0, false
+	fmt.Println("Uso de la sesión:")
+	for _, usage := range usages {
+		cost := "(sin precio)"
+		if value, ok := aiwrapper.EstimateCost(usage.Provider, usage.Tokens); ok {
+			cost = fmt.Sprintf("$%.4f", value)
+			totalCost += value
+			priced = true
+		}
+		fmt.Printf("  %-18s %4d peticiones  %8d tokens  %s\n", usage.Provider, usage.Requests, usage.Tokens, cost)
+		totalTokens += usage.Tokens
+	}
+	total := fmt.Sprintf("  %-18s %15s  %8d tokens", "Total", "", totalTokens)
+	if priced {
+		total += fmt.Sprintf("  $%.4f", totalCost)
+	}
+	fmt.Println(total)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/EmilianoMAl/AI-Wrapper/aiwrapper"
+)
+
+// runWorkflow muestra los pasos en orden y los ejecuta uno a uno con confirmación.
+// Si un paso falla o se omite, también se omiten los que dependen de él.
+func (ms *MiniShell) runWorkflow(reader *bufio.Reader, userInput string) {
+	ctx, done := ms.requestContext()
+	_, workflow, err := aiwrapper.TranslateWorkflow(ctx, userInput)
+	done()
+	if err != nil {
+		printError("Error procesando workflow: %v", err)
+		return
+	}
+	order, err := workflow.Order()
+	if err != nil {
+		printError("Error procesando workflow: %v", err)
+		return
+	}
+
+	fmt.Println("Workflow:")
+	for _, i := range order {
+		fmt.Printf("  [%d] %s", i, workflow[i].Command)
+		if len(workflow[i].After) > 0 {
+			fmt.Printf("  (después de %v)", workflow[i].After)
+		}
+		fmt.Println()
+	}
+	if !isTerminal(os.Stdin) {
+		return
+	}
+
+	succeeded := make([]bool, len(workflow))
+	for _, i := range order {
+		step := workflow[i]
+		blocked := false
+		for _, dep := range step.After {
+			if !succeeded[dep] {
+				blocked = true
+			}
+		}
+		if blocked {
+			fmt.Printf("Paso %d omitido: un paso previo no se completó\n", i)
+			continue
+		}
+
+		fmt.Printf("Paso %d: %s\n", i, step.Command)
+		if reason := stepBlockReason(step.Command); reason != "" {
+			printWarning("⚠️  Paso %d rechazado: %s", i, reason)
+			continue
+		}
+		dangerous, reason := aiwrapper.IsDangerous(step.Command)
+		if dangerous && !ms.confirmDangerous(reader, step.Command, reason) {
+			fmt.Println("Paso descartado")
+			continue
+		}
+		if !ms.confirmWithRisk(reader, step.Command, dangerous) {
+			continue
+		}
+
+		exitCode, err := ms.executeCommand(step.Command)
+		if err != nil {
+			printError("Error ejecutando comando: %v", err)
+			continue
+		}
+		fmt.Printf("Código de salida: %d\n", exitCode)
+		succeeded[i] = exitCode == 0
+	}
+}
+
+// stepBlockReason aplica a un paso del workflow los mismos rechazos que a un
+// comando suelto: código de otro lenguaje y, con AI_BLOCK_CHAINED, encadenamientos
+func stepBlockReason(command string) string {
+	if language := aiwrapper.ParseCommandInfo(command).Language; language != "" {
+		return fmt.Sprintf("es código %s, no un comando de shell", language)
+	}
+	if aiwrapper.GetEnvBool("AI_BLOCK_CHAINED", false) && aiwrapper.HasChainedCommands(command) {
+		return "encadena varios comandos"
+	}
+	return ""
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStepBlockReason(t *testing.T) {
+	tests := []struct {
+		command, blockChained, want string
+	}{
+		{"make", "true", ""},
+		{"make && make install", "false", ""},
+		{"make && make install", "true", "encadena varios comandos"},
+		{"ls & reboot", "true", "encadena varios comandos"},
+		{"```python\nimport os\n```", "false", "es código python"},
+	}
+	for _, tt := range tests {
+		t.Setenv("AI_BLOCK_CHAINED", tt.blockChained)
+		got := stepBlockReason(tt.command)
+		if tt.want == "" && got != "" || !strings.Contains(got, tt.want) {
+			t.Errorf("stepBlockReason(%q) with AI_BLOCK_CHAINED=%s = %q, want %q", tt.command, tt.blockChained, got, tt.want)
+		}
+	}
+}